@@ -0,0 +1,55 @@
+package api
+
+import (
+	"github.com/nekoite/go-napcat/message"
+)
+
+// MessageQuickOperation 消息事件的快速操作。
+type MessageQuickOperation struct {
+	// Reply 要回复的内容，为 nil 时不回复
+	Reply *message.Chain `json:"reply,omitempty"`
+	// AutoEscape 回复内容是否作为纯文本发送（不解析 CQ 码）
+	AutoEscape bool `json:"auto_escape,omitempty"`
+	// AtSender 是否要在回复开头 @ 发送者，仅群聊有效
+	AtSender bool `json:"at_sender"`
+	// Delete 撤回该条消息，仅群聊有效
+	Delete bool `json:"delete,omitempty"`
+	// Kick 把发送者踢出群组，仅群聊有效
+	Kick bool `json:"kick,omitempty"`
+	// Ban 禁言发送者，仅群聊有效
+	Ban bool `json:"ban,omitempty"`
+	// BanDuration 禁言时长（秒），仅群聊有效，为 0 时使用默认的 30 分钟
+	BanDuration int `json:"ban_duration,omitempty"`
+}
+
+// FriendRequestQuickOperation 加好友请求的快速操作。
+type FriendRequestQuickOperation struct {
+	Approve bool `json:"approve"`
+	// Remark 添加后的好友备注，仅在同意时有效
+	Remark string `json:"remark,omitempty"`
+}
+
+// GroupRequestQuickOperation 加群请求或邀请的快速操作。
+type GroupRequestQuickOperation struct {
+	Approve bool `json:"approve"`
+	// Reason 拒绝理由，仅在拒绝时有效
+	Reason string `json:"reason,omitempty"`
+}
+
+// QuickOpMessage 对消息事件进行快速操作。context 为事件的原始数据。
+func (s *Sender) QuickOpMessage(context any, operation *MessageQuickOperation) error {
+	_, err := s.QuickOp(context, operation)
+	return err
+}
+
+// QuickOpFriendRequest 对加好友请求进行快速操作。context 为事件的原始数据。
+func (s *Sender) QuickOpFriendRequest(context any, operation *FriendRequestQuickOperation) error {
+	_, err := s.QuickOp(context, operation)
+	return err
+}
+
+// QuickOpGroupRequest 对加群请求进行快速操作。context 为事件的原始数据。
+func (s *Sender) QuickOpGroupRequest(context any, operation *GroupRequestQuickOperation) error {
+	_, err := s.QuickOp(context, operation)
+	return err
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageQuickOperationJSON(t *testing.T) {
+	assert := assert.New(t)
+	b, err := json.Marshal(&MessageQuickOperation{Reply: message.NewText("hi").Segment().AsChain()})
+	assert.Nil(err)
+	assert.JSONEq(`{"reply":[{"type":"text","data":{"text":"hi"}}],"at_sender":false}`, string(b))
+
+	b, err = json.Marshal(&MessageQuickOperation{Ban: true, BanDuration: 60, AtSender: true})
+	assert.Nil(err)
+	assert.JSONEq(`{"at_sender":true,"ban":true,"ban_duration":60}`, string(b))
+}
+
+func TestRequestQuickOperationJSON(t *testing.T) {
+	assert := assert.New(t)
+	b, err := json.Marshal(&FriendRequestQuickOperation{Approve: false})
+	assert.Nil(err)
+	assert.JSONEq(`{"approve":false}`, string(b))
+
+	b, err = json.Marshal(&GroupRequestQuickOperation{Approve: false, Reason: "no"})
+	assert.Nil(err)
+	assert.JSONEq(`{"approve":false,"reason":"no"}`, string(b))
+}
+
+func TestParseRespActionHandleQuickOperation(t *testing.T) {
+	assert := assert.New(t)
+	resp := constructSuccessRespJson(t, map[string]any{})
+	r, err := parseResp(ActionHandleQuickOperation, makeSuccessApiResp(resp))
+	assert.Nil(err)
+	assert.IsType(&Resp[utils.Void]{}, r)
+}
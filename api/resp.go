@@ -198,7 +198,7 @@ func parseResp(action Action, data apiResp) (IResp, error) {
 		resp = &Resp[ServerStatus]{}
 	case ActionGetVersionInfo:
 		resp = &Resp[RespDataVersionInfo]{}
-	case ActionSetRestart, ActionCleanCache:
+	case ActionSetRestart, ActionCleanCache, ActionHandleQuickOperation:
 		resp = &Resp[utils.Void]{}
 	default:
 		if act, ok := extActions[action]; ok {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"sync"
@@ -143,7 +144,10 @@ func (s *Sender) HandleApiResp(resp []byte) error {
 	return errors.ErrUnknownResponse
 }
 
-func (s *Sender) sendRaw(action Action, params any, needResp bool) (IResp, error) {
+func (s *Sender) sendRaw(ctx context.Context, action Action, params any, needResp bool) (IResp, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	req := s.newReq(action, needResp)
 	apiReq := &apiReq{
 		Action: req.action,
@@ -165,25 +169,37 @@ func (s *Sender) sendRaw(action Action, params any, needResp bool) (IResp, error
 	case <-time.After(time.Duration(s.timeout) * time.Millisecond):
 		s.logger.Error("timeout", zap.String("action", string(action)), zap.Any("params", params), zap.Int("echo", int(req.id)))
 		return nil, errors.ErrTimeout
+	case <-ctx.Done():
+		s.reqMap.Delete(req.id)
+		return nil, ctx.Err()
 	}
 }
 
 // SendRaw 发送原始请求，等待并获取响应。函数将在等待响应送达后返回响应数据。
 // 如果响应超时，将返回 [errors.ErrTimeout]。
 func (s *Sender) SendRaw(action Action, params any) (IResp, error) {
-	return s.sendRaw(action, params, true)
+	return s.sendRaw(context.Background(), action, params, true)
+}
+
+// SendRawContext 与 [Sender.SendRaw] 相同，但 ctx 被取消时不再发送请求或等待响应，返回 ctx.Err()。
+func (s *Sender) SendRawContext(ctx context.Context, action Action, params any) (IResp, error) {
+	return s.sendRaw(ctx, action, params, true)
 }
 
 // SendRawNoResp 发送原始请求，不等待和获取响应。函数将会在发送请求后立即返回。
 // 如果请求由于网络原因未被接收，也不会报错。
 // 如果需要获取响应，请使用 [SendRaw]。
 func (s *Sender) SendRawNoResp(action Action, params any) error {
-	_, err := s.sendRaw(action, params, false)
+	_, err := s.sendRaw(context.Background(), action, params, false)
 	return err
 }
 
 func (s *Sender) SendPrivateMsgString(userId qq.UserId, message string, autoEscape bool) (*Resp[RespDataMessageId], error) {
-	return returnAsType[RespDataMessageId](s.SendRaw(ActionSendPrivateMsg, map[string]any{
+	return s.SendPrivateMsgStringContext(context.Background(), userId, message, autoEscape)
+}
+
+func (s *Sender) SendPrivateMsgStringContext(ctx context.Context, userId qq.UserId, message string, autoEscape bool) (*Resp[RespDataMessageId], error) {
+	return returnAsType[RespDataMessageId](s.SendRawContext(ctx, ActionSendPrivateMsg, map[string]any{
 		"user_id":     userId,
 		"message":     message,
 		"auto_escape": autoEscape,
@@ -191,14 +207,22 @@ func (s *Sender) SendPrivateMsgString(userId qq.UserId, message string, autoEsca
 }
 
 func (s *Sender) SendPrivateMsg(userId qq.UserId, message *message.Chain) (*Resp[RespDataMessageId], error) {
-	return returnAsType[RespDataMessageId](s.SendRaw(ActionSendPrivateMsg, map[string]any{
+	return s.SendPrivateMsgContext(context.Background(), userId, message)
+}
+
+func (s *Sender) SendPrivateMsgContext(ctx context.Context, userId qq.UserId, message *message.Chain) (*Resp[RespDataMessageId], error) {
+	return returnAsType[RespDataMessageId](s.SendRawContext(ctx, ActionSendPrivateMsg, map[string]any{
 		"user_id": userId,
 		"message": message,
 	}))
 }
 
 func (s *Sender) SendGroupMsgString(groupId qq.GroupId, message string, autoEscape bool) (*Resp[RespDataMessageId], error) {
-	return returnAsType[RespDataMessageId](s.SendRaw(ActionSendGroupMsg, map[string]any{
+	return s.SendGroupMsgStringContext(context.Background(), groupId, message, autoEscape)
+}
+
+func (s *Sender) SendGroupMsgStringContext(ctx context.Context, groupId qq.GroupId, message string, autoEscape bool) (*Resp[RespDataMessageId], error) {
+	return returnAsType[RespDataMessageId](s.SendRawContext(ctx, ActionSendGroupMsg, map[string]any{
 		"group_id":    groupId,
 		"message":     message,
 		"auto_escape": autoEscape,
@@ -206,7 +230,11 @@ func (s *Sender) SendGroupMsgString(groupId qq.GroupId, message string, autoEsca
 }
 
 func (s *Sender) SendGroupMsg(groupId qq.GroupId, message *message.Chain) (*Resp[RespDataMessageId], error) {
-	return returnAsType[RespDataMessageId](s.SendRaw(ActionSendGroupMsg, map[string]any{
+	return s.SendGroupMsgContext(context.Background(), groupId, message)
+}
+
+func (s *Sender) SendGroupMsgContext(ctx context.Context, groupId qq.GroupId, message *message.Chain) (*Resp[RespDataMessageId], error) {
+	return returnAsType[RespDataMessageId](s.SendRawContext(ctx, ActionSendGroupMsg, map[string]any{
 		"group_id": groupId,
 		"message":  message,
 	}))
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSendRawContextCancelled(t *testing.T) {
+	assert := assert.New(t)
+	// 连接为 nil，如果请求被发送将会 panic
+	s := NewSender(zap.NewNop(), nil, 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp, err := s.SendPrivateMsgContext(ctx, 1, nil)
+	assert.Nil(resp)
+	assert.ErrorIs(err, context.Canceled)
+	_, ok := s.reqMap.Load(int64(1))
+	assert.False(ok)
+}
@@ -1,14 +1,18 @@
 package gonapcat
 
 import (
+	"context"
 	errors2 "errors"
 	"fmt"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/nekoite/go-napcat/api"
 	"github.com/nekoite/go-napcat/config"
 	"github.com/nekoite/go-napcat/errors"
 	"github.com/nekoite/go-napcat/event"
+	"github.com/nekoite/go-napcat/i18n"
 	"github.com/nekoite/go-napcat/message"
 	"github.com/nekoite/go-napcat/qq"
 	"github.com/nekoite/go-napcat/utils"
@@ -32,6 +36,10 @@ type Bot struct {
 	conn       *ws.Client
 	dispatcher *event.Dispatcher
 	api        *api.Sender
+	dedup      *utils.TTLSet[string]
+	heartbeat  *event.HeartbeatMonitor
+	stop       chan struct{}
+	stopOnce   sync.Once
 
 	logger *BotLogger
 }
@@ -42,6 +50,8 @@ func NewBot(cfg *config.BotConfig) (*Bot, error) {
 		id:         qq.UserId(cfg.Id),
 		cfg:        cfg,
 		dispatcher: event.NewDispatcher(logger, cfg.UseGoroutine),
+		heartbeat:  event.NewHeartbeatMonitor(cfg.Heartbeat.MissedBeats),
+		stop:       make(chan struct{}),
 
 		logger: &BotLogger{logger: logger},
 	}
@@ -51,6 +61,44 @@ func NewBot(cfg *config.BotConfig) (*Bot, error) {
 		return nil, err
 	}
 	bot.api = api.NewSender(logger, bot.conn, cfg.ApiTimeout)
+	bot.dispatcher.SetPanicReply(cfg.PanicReply)
+	bot.dispatcher.SetPermissionDeniedReply(cfg.PermissionDeniedReply)
+	bot.dispatcher.SetRateLimitReply(cfg.CooldownReply, cfg.QuotaReply)
+	bot.dispatcher.SetCommandMentionMode(event.MentionMode(cfg.CommandMention))
+	replyPolicy := event.DefaultReplyPolicy()
+	replyPolicy.ParseError = cfg.ParseErrorReply
+	replyPolicy.ForwardThreshold = cfg.ReplyForwardThreshold
+	bot.dispatcher.SetReplyPolicy(replyPolicy)
+	bot.dispatcher.SetCommandTimeout(time.Duration(cfg.CommandTimeout) * time.Millisecond)
+	if len(cfg.CommandPrefixes) > 0 {
+		bot.dispatcher.SetGlobalCommandPrefixes(cfg.CommandPrefixes...)
+	}
+	bot.dispatcher.SetPrefixRequiredInGroupOnly(cfg.PrefixRequiredInGroupOnly)
+	if cfg.ScopeStorePath != "" {
+		scopes, err := event.NewScopeManager(event.NewFileScopeStore(cfg.ScopeStorePath))
+		if err != nil {
+			return nil, err
+		}
+		bot.dispatcher.SetScopeManager(scopes)
+	}
+	if cfg.LocaleDir != "" {
+		if err := bot.dispatcher.Catalog().LoadDir(cfg.LocaleDir); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Locale != "" {
+		bot.dispatcher.SetDefaultLocale(cfg.Locale)
+	}
+	for _, id := range cfg.Superusers {
+		bot.dispatcher.Permissions().AddSuperuser(qq.UserId(id))
+	}
+	if cfg.Dedup.Enabled {
+		bot.dedup = utils.NewTTLSet[string](time.Duration(cfg.Dedup.TTL)*time.Millisecond, cfg.Dedup.Capacity)
+	}
+	if cfg.WorkerPool.Enabled {
+		pool := event.NewWorkerPool(logger, cfg.WorkerPool.Workers, cfg.WorkerPool.QueueSize, event.OverflowPolicy(cfg.WorkerPool.OverflowPolicy), time.Duration(cfg.WorkerPool.BlockTimeout)*time.Millisecond)
+		bot.dispatcher.SetWorkerPool(pool)
+	}
 	return bot, nil
 }
 
@@ -102,10 +150,20 @@ func (b *Bot) RegisterHandlerPrivateMessage(h event.Handler) {
 	b.dispatcher.RegisterHandlerPrivateMessage(h)
 }
 
+// RegisterHandlerMessageSent 注册处理机器人自己发送的消息的处理器。
+func (b *Bot) RegisterHandlerMessageSent(h event.Handler) {
+	b.dispatcher.RegisterHandlerMessageSent(h)
+}
+
 func (b *Bot) RegisterHandlerNotice(h event.Handler) {
 	b.dispatcher.RegisterHandlerNotice(h)
 }
 
+// RegisterHandlerNoticeType 注册只处理 noticeType 类型通知事件的处理器。
+func (b *Bot) RegisterHandlerNoticeType(noticeType event.NoticeEventType, h event.Handler) {
+	b.dispatcher.RegisterHandlerNoticeType(noticeType, h)
+}
+
 func (b *Bot) RegisterHandlerMeta(h event.Handler) {
 	b.dispatcher.RegisterHandlerMeta(h)
 }
@@ -114,14 +172,100 @@ func (b *Bot) RegisterHandlerRequest(h event.Handler) {
 	b.dispatcher.RegisterHandlerRequest(h)
 }
 
-func (b *Bot) RegisterCommand(c event.ICommand) {
-	b.dispatcher.RegisterCommand(c)
+// RegisterInterceptor 注册消息拦截器，它在指令与处理器之前执行。返回 true 表示消息已被消费。
+func (b *Bot) RegisterInterceptor(i event.Interceptor) {
+	b.dispatcher.RegisterInterceptor(i)
+}
+
+// RegisterCommand 注册指令。指令的参数结构体定义有误等情况下返回错误，见 [event.CommandCenter.RegisterCommand]。
+func (b *Bot) RegisterCommand(c event.ICommand) error {
+	return b.dispatcher.RegisterCommand(c)
 }
 
 func (b *Bot) SetGlobalCommandPrefix(prefix string) {
 	b.dispatcher.SetGlobalCommandPrefix(prefix)
 }
 
+// SetGlobalCommandPrefixes 设置多个全局激活前缀，消息以其中任意一个开头即可。
+func (b *Bot) SetGlobalCommandPrefixes(prefixes ...string) {
+	b.dispatcher.SetGlobalCommandPrefixes(prefixes...)
+}
+
+// SetPrefixRequiredInGroupOnly 设置为 true 时只在群聊中需要全局激活前缀，私聊中可以省略。
+func (b *Bot) SetPrefixRequiredInGroupOnly(groupOnly bool) {
+	b.dispatcher.SetPrefixRequiredInGroupOnly(groupOnly)
+}
+
+// SetCommandMentionMode 设置指令是否需要 @ 机器人才能触发，见 [event.MentionMode]。
+func (b *Bot) SetCommandMentionMode(mode event.MentionMode) {
+	b.dispatcher.SetCommandMentionMode(mode)
+}
+
+// SetReplyPolicy 设置参数解析错误和指令返回值的自动回复方式，见 [event.ReplyPolicy]。
+func (b *Bot) SetReplyPolicy(policy event.ReplyPolicy) {
+	b.dispatcher.SetReplyPolicy(policy)
+}
+
+// SetCommandTimeout 设置指令默认的执行时间限制，超时或机器人关闭时 ParseResult.Context 将被取消。小于等于 0 时不限制。
+func (b *Bot) SetCommandTimeout(timeout time.Duration) {
+	b.dispatcher.SetCommandTimeout(timeout)
+}
+
+// Catalog 返回库生成的消息（权限不足、冷却、参数错误、帮助等）使用的消息目录，可以加载更多语言或覆盖内置的消息。
+func (b *Bot) Catalog() *i18n.Catalog {
+	return b.dispatcher.Catalog()
+}
+
+// SetDefaultLocale 设置库生成的消息默认使用的语言。每个群或私聊可以使用 `scope set locale <语言>` 单独设置。
+func (b *Bot) SetDefaultLocale(locale string) {
+	b.dispatcher.SetDefaultLocale(locale)
+}
+
+// NewHelpCommand 创建内置的帮助指令，可以修改它的设置后使用 RegisterCommand 注册。
+func (b *Bot) NewHelpCommand() *event.HelpCommand {
+	return b.dispatcher.NewHelpCommand()
+}
+
+// NewScopeCommand 创建管理当前聊天的指令开关与设置的内置指令（scope），使用 RegisterCommand 注册。
+func (b *Bot) NewScopeCommand() event.ICommand {
+	return b.dispatcher.NewScopeCommand()
+}
+
+// Scopes 返回管理每个群和私聊中功能开关与设置的范围管理器。
+func (b *Bot) Scopes() *event.ScopeManager {
+	return b.dispatcher.Scopes()
+}
+
+// UseScopeManager 使用指定的范围管理器，例如使用自定义存储的管理器。需要在 Start 之前调用。
+func (b *Bot) UseScopeManager(m *event.ScopeManager) {
+	b.dispatcher.SetScopeManager(m)
+}
+
+// Scoped 为处理器指定功能名称，处理器只在功能启用的群和私聊中被调用。
+func (b *Bot) Scoped(feature string, h event.Handler) event.Handler {
+	return b.dispatcher.Scoped(feature, h)
+}
+
+// Permissions 返回指令使用的权限管理器，可以用来添加超级用户或授予自定义权限节点。
+func (b *Bot) Permissions() *event.PermissionManager {
+	return b.dispatcher.Permissions()
+}
+
+// OnHandlerPanic 设置处理器或指令发生 panic 时的回调。panic 总是会被恢复并记录日志。
+func (b *Bot) OnHandlerPanic(h event.PanicHandler) {
+	b.dispatcher.SetOnHandlerPanic(h)
+}
+
+// WaitFor 阻塞直到下一条满足 filter 的消息到达，或 ctx 被取消。被等待到的消息不会再触发指令和其它处理器。
+func (b *Bot) WaitFor(ctx context.Context, filter event.MessageFilter) (event.IMessageEvent, error) {
+	return b.dispatcher.WaitFor(ctx, filter)
+}
+
+// NewSession 以 e 为起点创建多轮对话会话，用于等待同一用户在同一聊天中的后续消息。
+func (b *Bot) NewSession(e event.IMessageEvent) *event.Session {
+	return b.dispatcher.NewSession(e)
+}
+
 func (b *Bot) Start() error {
 	b.conn.Start()
 	err := b.initializeBotInfo()
@@ -130,11 +274,14 @@ func (b *Bot) Start() error {
 		b.Close()
 		return err
 	}
+	go b.monitorHeartbeat()
 	return nil
 }
 
 func (b *Bot) Close() {
+	b.stopOnce.Do(func() { close(b.stop) })
 	b.conn.Close()
+	b.dispatcher.Close()
 	b.logger.SyncLogger()
 }
 
@@ -334,6 +481,7 @@ func (b *Bot) initializeBotInfo() error {
 }
 
 func (b *Bot) onRecvWsMsg(msg []byte) {
+	defer b.recoverWsMsg(msg)
 	if utils.IsRawMessageApiResp(msg) {
 		err := utils.TimedFunc(func() error {
 			return b.api.HandleApiResp(msg)
@@ -345,6 +493,21 @@ func (b *Bot) onRecvWsMsg(msg []byte) {
 		}
 		return
 	}
+	if b.isDuplicateEvent(msg) {
+		return
+	}
+	if b.cfg.WorkerPool.Enabled {
+		// 工作池模式下需要按接收顺序提交事件
+		b.handleEvent(msg)
+		return
+	}
+	go func() {
+		defer b.recoverWsMsg(msg)
+		b.handleEvent(msg)
+	}()
+}
+
+func (b *Bot) handleEvent(msg []byte) {
 	e, err := event.ParseEvent(msg, b.api)
 	if err != nil {
 		if !errors2.Is(err, errors.ErrGoNapcat) {
@@ -353,11 +516,20 @@ func (b *Bot) onRecvWsMsg(msg []byte) {
 		}
 		b.logger.Warn("parse event", zap.Error(err))
 	}
+	if b.cfg.DropMessageSent && e.GetEventType() == event.EventTypeMessageSent {
+		return
+	}
+	if meta, ok := e.(*event.MetaEvent); ok && meta.MetaEventType == event.MetaEventTypeHeartbeat {
+		if b.heartbeat.Observe(meta, time.Now()) {
+			b.logger.Warn("server status unhealthy", zap.Any("status", meta.Status))
+			b.dispatchSynthetic(event.MetaEventTypeStatusUnhealthy)
+		}
+	}
 	b.logger.Debug("received event", zap.Any("event", e))
 	utils.TimedAction(func() {
 		b.dispatcher.Dispatch(e)
 	}, func(t time.Duration) {
-		if b.cfg.UseGoroutine {
+		if b.dispatcher.IsAsync() {
 			return
 		}
 		if t > 5*time.Second {
@@ -368,6 +540,73 @@ func (b *Bot) onRecvWsMsg(msg []byte) {
 	})
 }
 
+// Health 返回根据心跳得到的连接健康状况。
+func (b *Bot) Health() event.Health {
+	return b.heartbeat.Health()
+}
+
+// monitorHeartbeat 定期检查心跳是否超时。超时时分发 [event.MetaEventTypeHeartbeatTimeout] 元事件，并按配置强制重新连接。
+func (b *Bot) monitorHeartbeat() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			if !b.heartbeat.Check(now) {
+				continue
+			}
+			b.logger.Warn("heartbeat timeout", zap.Time("lastHeartbeat", b.heartbeat.Health().LastHeartbeat))
+			b.dispatchSynthetic(event.MetaEventTypeHeartbeatTimeout)
+			if b.cfg.Heartbeat.Reconnect {
+				b.conn.Reconnect()
+				b.heartbeat.Reset(time.Now())
+			}
+		}
+	}
+}
+
+func (b *Bot) dispatchSynthetic(metaEventType event.MetaEventType) {
+	e := event.NewSyntheticMetaEvent(b.id, metaEventType, b.heartbeat.Health(), b.api)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				b.logger.Error("panic while handling synthetic event", zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+			}
+		}()
+		b.dispatcher.Dispatch(e)
+	}()
+}
+
+// UseDedupCache 使用指定的去重缓存。多个连接到同一账号的机器人实例可以共用一个缓存，以便丢弃重复收到的事件。
+// 需要在 Start 之前调用。
+func (b *Bot) UseDedupCache(cache *utils.TTLSet[string]) {
+	b.dedup = cache
+}
+
+// isDuplicateEvent 在启用去重时检查事件是否已经收到过。
+func (b *Bot) isDuplicateEvent(msg []byte) bool {
+	if b.dedup == nil {
+		return false
+	}
+	key, ok := event.DedupKey(msg)
+	if !ok {
+		return false
+	}
+	if !b.dedup.AddIfAbsent(key) {
+		b.logger.Debug("dropped duplicate event", zap.String("key", key))
+		return true
+	}
+	return false
+}
+
+func (b *Bot) recoverWsMsg(msg []byte) {
+	if r := recover(); r != nil {
+		b.logger.Error("panic while handling ws message", zap.Any("panic", r), zap.ByteString("message", msg), zap.ByteString("stack", debug.Stack()))
+	}
+}
+
 func extractRespMessageId(r *api.Resp[api.RespDataMessageId], err error) (qq.MessageId, error) {
 	if err != nil {
 		return 0, err
@@ -14,6 +14,41 @@ type WsConfig struct {
 	Timeout     int // in milliseconds
 	PingPeriod  int // in milliseconds
 	PongTimeout int // in milliseconds
+	// RetryDelay 重新连接失败后第一次重试前的等待时间，之后每次失败翻倍，in milliseconds
+	RetryDelay int
+	// MaxRetryDelay 重试等待时间的上限，in milliseconds
+	MaxRetryDelay int
+}
+
+// WorkerPoolConfig 工作池分发模式的配置。启用后 UseGoroutine 无效。
+type WorkerPoolConfig struct {
+	Enabled bool
+	// Workers 工作协程数量。会话被散列到各个工作协程上
+	Workers int
+	// QueueSize 每个工作协程的队列长度
+	QueueSize int
+	// OverflowPolicy 队列已满时的策略：block，drop_newest，drop_oldest
+	OverflowPolicy string
+	// BlockTimeout block 策略下等待队列空位的最长时间，单位毫秒，超时后丢弃新的事件。
+	// 等待期间无法接收 API 响应，应当远小于 ApiTimeout。小于等于 0 时 block 策略将被替换为 drop_newest
+	BlockTimeout int
+}
+
+// DedupConfig 事件去重的配置
+type DedupConfig struct {
+	Enabled bool
+	// TTL 记录已处理事件的时间，单位毫秒
+	TTL int
+	// Capacity 最多记录的事件数量
+	Capacity int
+}
+
+// HeartbeatConfig 心跳监控的配置
+type HeartbeatConfig struct {
+	// MissedBeats 连续多少个心跳间隔没有收到心跳时视为超时
+	MissedBeats int
+	// Reconnect 心跳超时时是否强制重新连接
+	Reconnect bool
 }
 
 type BotConfig struct {
@@ -22,6 +57,39 @@ type BotConfig struct {
 	Debug        bool
 	UseGoroutine bool
 	ApiTimeout   int
+	WorkerPool   WorkerPoolConfig
+	Dedup        DedupConfig
+	Heartbeat    HeartbeatConfig
+	// DropMessageSent 为 true 时丢弃机器人自己发送的消息事件（message_sent）
+	DropMessageSent bool
+	// PanicReply 处理消息事件时发生 panic 后回复的内容，可以是消息目录中的键，例如 command.panic。为空时不回复
+	PanicReply string
+	// Superusers 机器人超级用户，拥有执行所有指令的权限
+	Superusers []int64
+	// PermissionDeniedReply 没有权限执行指令时回复的内容，为空时不回复
+	PermissionDeniedReply string
+	// CooldownReply 指令冷却中时回复的内容，{seconds} 将被替换为需要等待的秒数。为空时不回复
+	CooldownReply string
+	// QuotaReply 指令今日次数用完时回复的内容，为空时不回复
+	QuotaReply string
+	// CommandPrefixes 指令的全局激活前缀，消息以其中任意一个开头即可
+	CommandPrefixes []string
+	// PrefixRequiredInGroupOnly 为 true 时只在群聊中需要全局激活前缀
+	PrefixRequiredInGroupOnly bool
+	// CommandMention 指令是否需要 @ 机器人才能触发：空字符串（不处理 @），optional，required，required_in_group
+	CommandMention string
+	// ParseErrorReply 为 true 时由指令中心回复指令的参数解析错误及用法
+	ParseErrorReply bool
+	// ReplyForwardThreshold 自动回复的文本超过这个字符数时以合并转发消息发送，小于等于 0 时不使用
+	ReplyForwardThreshold int
+	// CommandTimeout 指令默认的执行时间限制，单位毫秒。超时后指令的 context 被取消，小于等于 0 时不限制
+	CommandTimeout int
+	// Locale 库生成的消息默认使用的语言，为空时使用 zh-CN
+	Locale string
+	// LocaleDir 消息目录文件所在的目录，其中的 <语言>.yaml 文件会被加载，可以添加语言或覆盖内置的消息
+	LocaleDir string
+	// ScopeStorePath 保存每个群和私聊的指令开关与设置的文件，为空时只保存在内存中
+	ScopeStorePath string
 }
 
 type LogConfig struct {
@@ -32,14 +100,29 @@ type LogConfig struct {
 
 var defaultBotCfg = BotConfig{
 	Ws: WsConfig{
-		Host:        "localhost",
-		Port:        3001,
-		Endpoint:    "/",
-		Timeout:     10000,
-		PingPeriod:  54000,
-		PongTimeout: 60000,
+		Host:          "localhost",
+		Port:          3001,
+		Endpoint:      "/",
+		Timeout:       10000,
+		PingPeriod:    54000,
+		PongTimeout:   60000,
+		RetryDelay:    1000,
+		MaxRetryDelay: 30000,
 	},
 	ApiTimeout: 30000,
+	WorkerPool: WorkerPoolConfig{
+		Workers:        16,
+		QueueSize:      64,
+		OverflowPolicy: "drop_newest",
+		BlockTimeout:   1000,
+	},
+	Dedup: DedupConfig{
+		TTL:      300000,
+		Capacity: 4096,
+	},
+	Heartbeat: HeartbeatConfig{
+		MissedBeats: 3,
+	},
 }
 
 func BotConfigFromYamlFile(path string) (*BotConfig, error) {
@@ -83,11 +166,102 @@ func (c *BotConfig) GoroutineMode(useGoroutine bool) *BotConfig {
 	return c
 }
 
+// WithWorkerPool 启用工作池分发模式。
+func (c *BotConfig) WithWorkerPool(workers int, queueSize int, overflowPolicy string) *BotConfig {
+	c.WorkerPool.Enabled = true
+	c.WorkerPool.Workers = workers
+	c.WorkerPool.QueueSize = queueSize
+	c.WorkerPool.OverflowPolicy = overflowPolicy
+	return c
+}
+
+// WithDedup 启用事件去重。ttl 单位为毫秒。
+func (c *BotConfig) WithDedup(ttl int, capacity int) *BotConfig {
+	c.Dedup.Enabled = true
+	c.Dedup.TTL = ttl
+	c.Dedup.Capacity = capacity
+	return c
+}
+
+// WithHeartbeat 设置心跳监控。连续 missedBeats 个心跳间隔没有收到心跳时视为超时，reconnect 为 true 时超时后强制重新连接。
+func (c *BotConfig) WithHeartbeat(missedBeats int, reconnect bool) *BotConfig {
+	c.Heartbeat.MissedBeats = missedBeats
+	c.Heartbeat.Reconnect = reconnect
+	return c
+}
+
+func (c *BotConfig) WithDropMessageSent(drop bool) *BotConfig {
+	c.DropMessageSent = drop
+	return c
+}
+
 func (c *BotConfig) WithApiTimeout(timeout int) *BotConfig {
 	c.ApiTimeout = timeout
 	return c
 }
 
+func (c *BotConfig) WithPanicReply(reply string) *BotConfig {
+	c.PanicReply = reply
+	return c
+}
+
+// WithSuperusers 添加机器人超级用户。
+func (c *BotConfig) WithSuperusers(userIds ...int64) *BotConfig {
+	c.Superusers = append(c.Superusers, userIds...)
+	return c
+}
+
+func (c *BotConfig) WithPermissionDeniedReply(reply string) *BotConfig {
+	c.PermissionDeniedReply = reply
+	return c
+}
+
+// WithRateLimitReply 设置指令冷却中和今日次数用完时回复的内容。cooldown 中的 {seconds} 将被替换为需要等待的秒数。
+func (c *BotConfig) WithRateLimitReply(cooldown string, quota string) *BotConfig {
+	c.CooldownReply = cooldown
+	c.QuotaReply = quota
+	return c
+}
+
+// WithCommandMention 设置指令是否需要 @ 机器人才能触发：optional，required，required_in_group。
+func (c *BotConfig) WithCommandMention(mode string) *BotConfig {
+	c.CommandMention = mode
+	return c
+}
+
+// WithScopeStore 将每个群和私聊的指令开关与设置保存在 path 文件中。
+func (c *BotConfig) WithScopeStore(path string) *BotConfig {
+	c.ScopeStorePath = path
+	return c
+}
+
+// WithCommandPrefixes 设置指令的全局激活前缀。groupOnly 为 true 时只在群聊中需要前缀。
+func (c *BotConfig) WithCommandPrefixes(groupOnly bool, prefixes ...string) *BotConfig {
+	c.CommandPrefixes = prefixes
+	c.PrefixRequiredInGroupOnly = groupOnly
+	return c
+}
+
+// WithParseErrorReply 设置是否由指令中心回复参数解析错误及用法。自动回复的文本超过 forwardThreshold 个字符时以合并转发消息发送，小于等于 0 时不使用。
+func (c *BotConfig) WithParseErrorReply(enabled bool, forwardThreshold int) *BotConfig {
+	c.ParseErrorReply = enabled
+	c.ReplyForwardThreshold = forwardThreshold
+	return c
+}
+
+// WithCommandTimeout 设置指令默认的执行时间限制，单位毫秒。
+func (c *BotConfig) WithCommandTimeout(timeout int) *BotConfig {
+	c.CommandTimeout = timeout
+	return c
+}
+
+// WithLocale 设置库生成的消息默认使用的语言，并从 dir 加载消息目录文件。dir 为空时只使用内置的消息。
+func (c *BotConfig) WithLocale(locale string, dir string) *BotConfig {
+	c.Locale = locale
+	c.LocaleDir = dir
+	return c
+}
+
 func DefaultLogConfig() *LogConfig {
 	return &LogConfig{
 		Level: "info",
@@ -23,9 +23,16 @@ var (
 
 	ErrExtensionAlreadyRegistered = fmt.Errorf("%w: extension already registered", ErrGoNapcat)
 	ErrActionAlreadyRegistered    = fmt.Errorf("%w: action already registered", ErrGoNapcat)
+	ErrEventAlreadyRegistered     = fmt.Errorf("%w: event already registered", ErrGoNapcat)
+
+	ErrUnknownMachine           = fmt.Errorf("%w: unknown state machine", ErrGoNapcat)
+	ErrUnknownStep              = fmt.Errorf("%w: unknown state machine step", ErrGoNapcat)
+	ErrMachineAlreadyRegistered = fmt.Errorf("%w: state machine already registered", ErrGoNapcat)
 
 	ErrUnsupportedOperation = fmt.Errorf("%w: unsupported operation", ErrGoNapcat)
 	ErrTimeout              = fmt.Errorf("%w: timeout", ErrGoNapcat)
+	ErrNoQuotedMessage      = fmt.Errorf("%w: no quoted message", ErrGoNapcat)
+	ErrInvalidCommand       = fmt.Errorf("%w: invalid command", ErrGoNapcat)
 
 	ErrTypeAssertion = errors.New("type assertion failed")
 )
@@ -0,0 +1,146 @@
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
+)
+
+// 以下类型可以用作指令参数结构体中的字段类型，kong 会将对应的 CQ 码参数解码为相应的值。
+// 使用时指令的 SplitBySpaceOnly 应返回 false，以便 CQ 码成为单独的参数。
+
+// AtArg @ 消息片段参数，例如 [CQ:at,qq=123456]。
+type AtArg struct {
+	UserId qq.UserId
+	// All 是否为 @全体成员
+	All bool
+}
+
+func (a *AtArg) Decode(ctx *kong.DecodeContext) error {
+	seg, err := popSegment(ctx, message.SegmentTypeAt)
+	if err != nil {
+		return err
+	}
+	a.UserId, a.All, err = atTarget(seg)
+	return err
+}
+
+// UserIdArg 用户参数，可以是 @ 消息片段，也可以是 QQ 号。
+type UserIdArg struct {
+	UserId qq.UserId
+}
+
+func (u *UserIdArg) Decode(ctx *kong.DecodeContext) error {
+	var s string
+	if err := ctx.Scan.PopValueInto("user", &s); err != nil {
+		return err
+	}
+	if id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil && id > 0 {
+		u.UserId = qq.UserId(id)
+		return nil
+	}
+	seg, err := parseSingleSegment(s, message.SegmentTypeAt)
+	if err != nil {
+		return fmt.Errorf("expected @user or QQ number but got %q", message.UnescapeCQString(s))
+	}
+	userId, all, err := atTarget(seg)
+	if err != nil {
+		return err
+	}
+	if all {
+		return fmt.Errorf("expected @user or QQ number but got @all")
+	}
+	u.UserId = userId
+	return nil
+}
+
+// ImageArg 图片消息片段参数。
+type ImageArg struct {
+	Image *message.ImageData
+}
+
+func (i *ImageArg) Decode(ctx *kong.DecodeContext) error {
+	seg, err := popSegment(ctx, message.SegmentTypeImage)
+	if err != nil {
+		return err
+	}
+	i.Image = seg.GetImageData()
+	if i.Image == nil {
+		return fmt.Errorf("expected an image segment")
+	}
+	return nil
+}
+
+// ChainArg 任意内容的参数，解码为消息链。
+type ChainArg struct {
+	Chain *message.Chain
+}
+
+func (c *ChainArg) Decode(ctx *kong.DecodeContext) error {
+	var s string
+	if err := ctx.Scan.PopValueInto("message", &s); err != nil {
+		return err
+	}
+	chain, err := message.ParseCQString(s)
+	if err != nil {
+		return err
+	}
+	c.Chain = chain
+	return nil
+}
+
+// atTarget 返回 @ 消息片段的目标。
+func atTarget(seg message.Segment) (qq.UserId, bool, error) {
+	data := seg.GetAtData()
+	if data == nil {
+		return 0, false, fmt.Errorf("expected an at segment")
+	}
+	if data.QQ == "all" {
+		return 0, true, nil
+	}
+	id, err := strconv.ParseInt(data.QQ, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid at target %q", data.QQ)
+	}
+	return qq.UserId(id), false, nil
+}
+
+// popSegment 读取一个参数，并解析为指定类型的单个消息片段。
+func popSegment(ctx *kong.DecodeContext, ty message.SegmentType) (message.Segment, error) {
+	var s string
+	if err := ctx.Scan.PopValueInto(string(ty), &s); err != nil {
+		return message.Segment{}, err
+	}
+	return parseSingleSegment(s, ty)
+}
+
+func parseSingleSegment(s string, ty message.SegmentType) (message.Segment, error) {
+	chain, err := message.ParseCQString(strings.TrimSpace(s))
+	if err != nil {
+		return message.Segment{}, err
+	}
+	if chain.Len() != 1 || chain.At(0).Type != ty {
+		return message.Segment{}, fmt.Errorf("expected %s segment but got %q", ty, message.UnescapeCQString(s))
+	}
+	return chain.At(0), nil
+}
+
+// stripReply 去掉消息开头的回复消息片段，返回剩余的消息与被回复的消息 ID。
+func stripReply(raw string) (string, qq.MessageId) {
+	if !strings.HasPrefix(raw, "[CQ:reply,") {
+		return raw, 0
+	}
+	end := strings.Index(raw, "]")
+	if end == -1 {
+		return raw, 0
+	}
+	seg, err := parseSingleSegment(raw[:end+1], message.SegmentTypeReply)
+	if err != nil || seg.GetReplyData() == nil {
+		return raw, 0
+	}
+	return strings.TrimLeft(raw[end+1:], " "), qq.MessageId(seg.GetReplyData().Id)
+}
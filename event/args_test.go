@@ -0,0 +1,80 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testTypedArgs struct {
+	Target UserIdArg `arg:""`
+	At     *AtArg    `short:"a" optional:""`
+	Image  *ImageArg `short:"i" optional:""`
+	Rest   ChainArg  `arg:"" optional:""`
+}
+
+func dispatchTypedArgs(raw string) *ParseResult {
+	d := NewDispatcher(zap.NewNop(), false)
+	var res *ParseResult
+	d.RegisterCommand(&testCommand{
+		name:      "t",
+		mode:      CmdNameModeNormal,
+		getNew:    func() any { return &testTypedArgs{} },
+		onCommand: func(parseResult *ParseResult) { res = parseResult },
+	})
+	d.Dispatch(newTestPrivateMessageEvent(raw))
+	return res
+}
+
+func TestTypedArgs(t *testing.T) {
+	assert := assert.New(t)
+	res := dispatchTypedArgs("t [CQ:at,qq=123] -a [CQ:at,qq=all] -i [CQ:image,file=a.jpg] hello&amp;")
+	assert.NoError(res.Error)
+	args := res.ParsedArgs.(*testTypedArgs)
+	assert.Equal(qq.UserId(123), args.Target.UserId)
+	assert.True(args.At.All)
+	assert.Equal("a.jpg", args.Image.Image.File)
+	assert.Equal("hello&", args.Rest.Chain.FirstText().Text)
+
+	res = dispatchTypedArgs("t 456 -a [CQ:at,qq=789]")
+	assert.NoError(res.Error)
+	args = res.ParsedArgs.(*testTypedArgs)
+	assert.Equal(qq.UserId(456), args.Target.UserId)
+	assert.Equal(qq.UserId(789), args.At.UserId)
+	assert.Nil(args.Image)
+
+	res = dispatchTypedArgs("t abc")
+	assert.ErrorContains(res.Error, `expected @user or QQ number but got "abc"`)
+
+	res = dispatchTypedArgs("t 1 -i [CQ:at,qq=1]")
+	assert.ErrorContains(res.Error, "expected image segment")
+}
+
+func TestCommandWithReply(t *testing.T) {
+	assert := assert.New(t)
+	res := dispatchTypedArgs("[CQ:reply,id=42] t 1")
+	assert.NotNil(res)
+	assert.NoError(res.Error)
+	assert.Equal(qq.MessageId(42), res.ReplyTo)
+	assert.Equal(qq.UserId(1), res.ParsedArgs.(*testTypedArgs).Target.UserId)
+	_, err := res.GetQuotedMessage()
+	assert.ErrorIs(err, errors.ErrUnsupportedOperation)
+
+	res = dispatchTypedArgs("t 1")
+	assert.Equal(qq.MessageId(0), res.ReplyTo)
+	_, err = res.GetQuotedMessage()
+	assert.ErrorIs(err, errors.ErrNoQuotedMessage)
+}
+
+func TestStripReply(t *testing.T) {
+	assert := assert.New(t)
+	rest, id := stripReply("[CQ:reply,id=-5]hi")
+	assert.Equal("hi", rest)
+	assert.Equal(qq.MessageId(-5), id)
+	rest, id = stripReply("[CQ:at,qq=1]hi")
+	assert.Equal("[CQ:at,qq=1]hi", rest)
+	assert.Equal(qq.MessageId(0), id)
+}
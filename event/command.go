@@ -1,23 +1,56 @@
 package event
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/nekoite/go-napcat/api"
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/nekoite/go-napcat/i18n"
 	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
 	"go.uber.org/zap"
 )
 
 type CmdNameMode string
 
+// 指令匹配的优先级为：CmdNameModeNormal > CmdNameModePrefix > CmdNameModeRegex > CmdNameModeKeyword。
 const (
 	CmdNameModePrefix CmdNameMode = "prefix"
 	CmdNameModeNormal CmdNameMode = "normal"
+	// CmdNameModeRegex 名称为正则表达式，在消息的任意位置匹配，不需要全局激活前缀。
+	// 命名捕获组见 [ParseResult.Captures]，kong 将解析空的参数列表
+	CmdNameModeRegex CmdNameMode = "regex"
+	// CmdNameModeKeyword 名称为关键词，消息中包含关键词即匹配，不需要全局激活前缀。kong 将解析空的参数列表
+	CmdNameModeKeyword CmdNameMode = "keyword"
 )
 
 type ParseResult struct {
-	Ctx        *kong.Context
-	Event      IMessageEvent
+	// Context 在指令超时（见 [ICommandWithTimeout] 与 [CommandCenter.SetCommandTimeout]）或机器人关闭时被取消。
+	Context context.Context
+	// Ctx kong 的解析上下文，解析失败时为 nil。它引用的解析器会在指令执行完毕后被复用，不要在指令返回后继续使用
+	Ctx *kong.Context
+	// Event 指令消息的副本，它的 Reply 等函数使用 Context 发送消息：Context 被取消后不再发送。
+	// 对它调用 PreventDefault 同样会阻止原事件继续传播
+	Event IMessageEvent
+	// Locale 指令所在的群或私聊使用的语言，见 [CommandCenter.Locale]
+	Locale string
+	// Name 消息中匹配到的指令名称或别名（已反转义）
+	Name string
+	// Prefix 消息中匹配到的全局激活前缀，没有使用前缀时为空字符串
+	Prefix string
+	// ReplyTo 指令消息开头的回复消息片段所引用的消息 ID，没有引用时为 0
+	ReplyTo qq.MessageId
+	// Captures 正则指令中命名捕获组匹配到的内容
+	Captures map[string]string
+	// Subcommand 匹配到的子指令路径，多级子指令以空格分隔，例如 "user add"。没有子指令时为空字符串
+	Subcommand string
 	ParsedArgs any
 	Error      error
 	ExitCode   int
@@ -25,123 +58,426 @@ type ParseResult struct {
 	StdErr     string
 }
 
+// ICommand 指令需要实现的接口。可以嵌入 CommandBase 获得除 GetName 外方法的默认实现，
+// 其它可选功能见 ICommandWith* 等接口，签名不符的同名方法会在注册时记录警告。
 type ICommand interface {
 	GetName() (string, CmdNameMode)
-	// GetNew 用于获取一个新的命令行参数定义结构体。
-	// 它将被传入回调函数。具体请参考 kong 的文档。
+	// GetNew 用于获取一个新的命令行参数定义结构体。具体请参考 kong 的文档。
+	// 它在注册指令时（以及并发调用需要更多解析器时）被调用以创建 kong 解析器，解析结果会被复制到新的结构体中传入回调函数。
 	GetNew() any
 	GetOptions() []kong.Option
 	SplitBySpaceOnly() bool
 	OnCommand(parseResult *ParseResult)
 }
 
+// ICommandWithAliases 声明指令的别名。别名与指令名称使用相同的名称模式，例如可以同时使用中文和英文名称。
+type ICommandWithAliases interface {
+	GetAliases() []string
+}
+
+// ICommandWithDescription 提供指令的简短描述，用于帮助指令的列表。
+type ICommandWithDescription interface {
+	GetDescription() string
+}
+
+// ICommandWithPreprocess 在参数分割前处理指令名称之后的剩余部分，返回处理后的字符串。
 type ICommandWithPreprocess interface {
 	Preprocess(remaining string) string
 }
 
+// ICommandStopPropagation 决定指令处理完成后是否停止事件传播，没有实现此接口的指令不会停止传播。
 type ICommandStopPropagation interface {
 	// StopPropagation 返回 true 时，事件将在指令处理完成后停止继续处理其它处理器。
 	StopPropagation() bool
 }
 
 type CommandCenter struct {
-	logger       *zap.Logger
-	globalPrefix string
+	logger         *zap.Logger
+	globalPrefixes []string
+	// prefixInGroupOnly 为 true 时私聊中的指令可以省略全局激活前缀
+	prefixInGroupOnly bool
+	permissions       *PermissionManager
+	deniedReply       string
+	rateLimiter       *RateLimiter
+	cooldownReply     string
+	quotaReply        string
+	mentionMode       MentionMode
+	scopes            *ScopeManager
+	replyPolicy       ReplyPolicy
+	// ctx 在指令中心关闭时被取消，是所有指令调用的 context 的父 context
+	ctx            context.Context
+	cancel         context.CancelFunc
+	commandTimeout time.Duration
+	catalog        *i18n.Catalog
+	defaultLocale  string
 
-	Commands       map[string]ICommand
-	PrefixCommands []ICommand
+	Commands        map[string]ICommand
+	PrefixCommands  []ICommand
+	RegexCommands   []ICommand
+	KeywordCommands []ICommand
+	regexps         map[string]*regexp.Regexp
+	parsers         map[ICommand]*parserPool
 }
 
 func NewParseResult() *ParseResult {
 	return &ParseResult{}
 }
 
+// GetQuotedMessage 获取指令消息引用的消息。没有引用时返回 [errors.ErrNoQuotedMessage]。
+func (p *ParseResult) GetQuotedMessage() (*api.RespDataMessage, error) {
+	if p.ReplyTo == 0 {
+		return nil, errors.ErrNoQuotedMessage
+	}
+	if p.Event == nil || p.Event.getApiSender() == nil {
+		return nil, errors.ErrUnsupportedOperation
+	}
+	resp, err := p.Event.getApiSender().GetMsg(p.ReplyTo)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
 func NewCommandCenter(logger *zap.Logger) *CommandCenter {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &CommandCenter{
+		ctx:            ctx,
+		cancel:         cancel,
 		logger:         logger.Named("command"),
+		permissions:    NewPermissionManager(),
+		rateLimiter:    NewRateLimiter(),
+		scopes:         newMemoryScopeManager(),
+		replyPolicy:    DefaultReplyPolicy(),
+		catalog:        i18n.DefaultCatalog(),
+		defaultLocale:  i18n.DefaultLocale,
 		Commands:       make(map[string]ICommand),
 		PrefixCommands: make([]ICommand, 0),
+		regexps:        make(map[string]*regexp.Regexp),
+		parsers:        make(map[ICommand]*parserPool),
 	}
 }
 
-func (c *CommandCenter) RegisterCommand(command ICommand) {
+// RegisterCommand 注册指令。指令的 kong 解析器在注册时创建，参数结构体的定义有误、正则表达式无效或者名称模式未知时返回错误，
+// 此时指令不会被注册。
+func (c *CommandCenter) RegisterCommand(command ICommand) error {
 	name, mode := command.GetName()
+	// 解析器以指令为键保存
+	if !reflect.TypeOf(command).Comparable() {
+		return fmt.Errorf("%w: %s: command type %T is not comparable", errors.ErrInvalidCommand, name, command)
+	}
+	c.checkCapabilities(command)
+	pool, err := newParserPool(command)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", errors.ErrInvalidCommand, name, err)
+	}
 	switch mode {
 	case CmdNameModePrefix:
 		c.PrefixCommands = append(c.PrefixCommands, command)
 	case CmdNameModeNormal:
-		c.Commands[name] = command
+		for _, name := range commandNames(command) {
+			if _, ok := c.Commands[name]; ok {
+				c.logger.Warn("command name already registered, overriding", zap.String("name", name))
+			}
+			c.Commands[name] = command
+		}
+	case CmdNameModeRegex:
+		if err := c.registerRegexCommand(command); err != nil {
+			return fmt.Errorf("%w: %s: %w", errors.ErrInvalidCommand, name, err)
+		}
+	case CmdNameModeKeyword:
+		c.KeywordCommands = append(c.KeywordCommands, command)
 	default:
-		c.logger.Error("unknown command name mode", zap.String("mode", string(mode)))
+		return fmt.Errorf("%w: %s: unknown command name mode %q", errors.ErrInvalidCommand, name, mode)
 	}
+	c.parsers[command] = pool
+	return nil
 }
 
 func (c *CommandCenter) SetGlobalCommandPrefix(prefix string) {
 	if len(prefix) == 0 {
 		return
 	}
-	c.globalPrefix = prefix
+	c.globalPrefixes = []string{prefix}
 }
 
-func (c *CommandCenter) onMessageRecv(event IMessageEvent) {
-	if len(c.Commands) == 0 && len(c.PrefixCommands) == 0 {
+// SetGlobalCommandPrefixes 设置多个全局激活前缀，消息以其中任意一个开头即可。不提供前缀表示不需要前缀。
+func (c *CommandCenter) SetGlobalCommandPrefixes(prefixes ...string) {
+	c.globalPrefixes = slices.Clone(prefixes)
+}
+
+// SetPrefixRequiredInGroupOnly 设置为 true 时只在群聊中需要全局激活前缀，私聊中可以省略。
+func (c *CommandCenter) SetPrefixRequiredInGroupOnly(groupOnly bool) {
+	c.prefixInGroupOnly = groupOnly
+}
+
+// Scopes 返回指令中心使用的范围管理器。
+func (c *CommandCenter) Scopes() *ScopeManager {
+	return c.scopes
+}
+
+// SetScopeManager 使用指定的范围管理器。
+func (c *CommandCenter) SetScopeManager(m *ScopeManager) {
+	if m == nil {
 		return
 	}
-	rawMsg := event.GetRawMessage()
-	cmd, prefix := c.getCommand(rawMsg)
-	if cmd == nil {
+	c.scopes = m
+}
+
+// isEnabled 判断指令在事件所在的范围中是否启用。功能名称为指令名称。
+func (c *CommandCenter) isEnabled(event IMessageEvent, cmd ICommand) bool {
+	if _, ok := cmd.(interface{ alwaysEnabled() }); ok {
+		return true
+	}
+	name, _ := cmd.GetName()
+	return c.scopes.IsEnabledFor(event, name)
+}
+
+// prefixesFor 返回事件所在范围的全局激活前缀。其中的空字符串表示可以省略前缀。
+func (c *CommandCenter) prefixesFor(event IMessageEvent) []string {
+	prefixes := c.globalPrefixes
+	if scope, ok := ScopeOf(event); ok {
+		if scopePrefixes, ok := c.scopes.Prefixes(scope); ok {
+			prefixes = scopePrefixes
+		}
+	}
+	if c.prefixInGroupOnly && len(prefixes) > 0 && event.GetMessageEventType() != MessageEventTypeGroup {
+		prefixes = append(slices.Clone(prefixes), "")
+	}
+	return prefixes
+}
+
+// Permissions 返回指令中心使用的权限管理器。
+func (c *CommandCenter) Permissions() *PermissionManager {
+	return c.permissions
+}
+
+// SetPermissionManager 使用指定的权限管理器，可以在多个机器人之间共用。
+func (c *CommandCenter) SetPermissionManager(m *PermissionManager) {
+	if m == nil {
 		return
 	}
+	c.permissions = m
+}
+
+// SetPermissionDeniedReply 设置没有权限执行指令时回复的内容，可以是消息目录中的键，例如 command.permission_denied。为空字符串时不回复（默认）。
+func (c *CommandCenter) SetPermissionDeniedReply(reply string) {
+	c.deniedReply = reply
+}
+
+// missingPermission 返回调用者缺少的第一个权限。
+func (c *CommandCenter) missingPermission(event IMessageEvent, cmd ICommand) (Permission, bool) {
+	permCmd, ok := cmd.(ICommandWithPermission)
+	if !ok {
+		return "", true
+	}
+	return c.permissions.HasAll(event, permCmd.GetPermissions())
+}
+
+// hasPermission 判断调用者是否拥有执行指令需要的所有权限。
+func (c *CommandCenter) hasPermission(event IMessageEvent, cmd ICommand) bool {
+	_, ok := c.missingPermission(event, cmd)
+	return ok
+}
+
+// checkPermission 检查调用者是否拥有执行指令需要的权限，没有权限时按设置回复。
+func (c *CommandCenter) checkPermission(event IMessageEvent, cmd ICommand) bool {
+	missing, ok := c.missingPermission(event, cmd)
+	if ok {
+		return true
+	}
 	cmdName, _ := cmd.GetName()
-	parseResult := NewParseResult()
-	stdout := strings.Builder{}
-	stderr := strings.Builder{}
-	options := []kong.Option{
-		kong.Exit(func(i int) { parseResult.ExitCode = i }),
-		kong.Writers(&stdout, &stderr),
-		kong.Name(cmdName),
-	}
-	gram := cmd.GetNew()
-	k, err := kong.New(
-		gram,
-		append(options, cmd.GetOptions()...)...,
-	)
+	c.logger.Debug("permission denied", zap.String("command", cmdName), zap.Int64("user", int64(event.GetUserId())), zap.String("permission", string(missing)))
+	if c.deniedReply != "" {
+		reply := c.Translate(event, c.deniedReply, nil)
+		if _, err := event.Reply(message.NewText(reply).Segment().AsChain(), true); err != nil {
+			c.logger.Error("failed to send permission denied reply", zap.Error(err))
+		}
+	}
+	return false
+}
+
+// SetRateLimitReply 设置指令冷却中和今日次数用完时回复的内容，可以是消息目录中的键，例如 command.cooldown 与 command.quota。
+// cooldown 中的 {seconds} 将被替换为需要等待的秒数。为空字符串时不回复（默认）。
+func (c *CommandCenter) SetRateLimitReply(cooldown string, quota string) {
+	c.cooldownReply = cooldown
+	c.quotaReply = quota
+}
+
+// checkRateLimit 检查指令的频率限制，超过限制时按设置回复。
+func (c *CommandCenter) checkRateLimit(event IMessageEvent, cmd ICommand) bool {
+	limitCmd, ok := cmd.(ICommandWithRateLimit)
+	if !ok || c.permissions.IsSuperuser(event.GetUserId()) {
+		return true
+	}
+	cmdName, _ := cmd.GetName()
+	result := c.rateLimiter.Take(cmdName, event, limitCmd.GetRateLimit())
+	if result.Allowed {
+		return true
+	}
+	c.logger.Debug("rate limited", zap.String("command", cmdName), zap.Int64("user", int64(event.GetUserId())), zap.Duration("wait", result.Wait), zap.Bool("quotaExceeded", result.QuotaExceeded))
+	reply := c.Translate(event, c.quotaReply, nil)
+	if !result.QuotaExceeded {
+		reply = formatCooldownReply(c.Translate(event, c.cooldownReply, nil), result)
+	}
+	if reply != "" {
+		if _, err := event.Reply(message.NewText(reply).Segment().AsChain(), true); err != nil {
+			c.logger.Error("failed to send rate limit reply", zap.Error(err))
+		}
+	}
+	return false
+}
+
+func (c *CommandCenter) onMessageRecv(event IMessageEvent) {
+	if len(c.Commands) == 0 && len(c.PrefixCommands) == 0 && len(c.RegexCommands) == 0 && len(c.KeywordCommands) == 0 {
+		return
+	}
+	// 防止机器人执行自己发送的指令而导致循环
+	if event.GetEventType() != EventTypeMessage || isFromSelf(event) {
+		return
+	}
+	rawMsg, replyTo := stripReply(event.GetRawMessage())
+	rawMsg, mentioned := c.stripMention(event, rawMsg)
+	var cmd ICommand
+	var name, prefix, remaining string
+	var match *triggerMatch
+	if m := c.matchCommand(rawMsg, c.prefixesFor(event)); m != nil {
+		cmd = m.cmd
+		name = message.UnescapeCQString(m.name)
+		prefix = m.prefix
+		remaining = rawMsg[len(m.consumed):]
+	} else if cmd, match = c.getTriggerCommand(rawMsg); cmd != nil {
+		name = match.name
+	} else {
+		return
+	}
+	if !c.checkMention(event, mentioned, replyTo) {
+		return
+	}
+	if !c.isEnabled(event, cmd) || !c.checkPermission(event, cmd) {
+		return
+	}
+	pool, err := c.parserPool(cmd)
+	if err != nil {
+		c.logger.Error("failed to create kong", zap.Error(err))
+		return
+	}
+	parser, err := pool.get()
 	if err != nil {
 		c.logger.Error("failed to create kong", zap.Error(err))
 		return
 	}
-	remaining := rawMsg[len(prefix):]
+	// 指令执行完毕前解析器不能被其它调用使用，ParseResult.Ctx 仍然引用它
+	defer pool.put(parser)
+	parseResult := NewParseResult()
+	parseResult.Locale = c.Locale(event)
+	// --help 的输出使用翻译后的帮助文本，需要在放回解析器之前恢复
+	defer c.translateHelp(parser.kong, parseResult.Locale)()
+	stdout := strings.Builder{}
+	stderr := strings.Builder{}
+	// kong 在输出 --help 等内容后调用 Exit
+	exited := false
+	parser.kong.Exit = func(i int) {
+		parseResult.ExitCode = i
+		exited = true
+	}
+	parser.kong.Stdout = &stdout
+	parser.kong.Stderr = &stderr
 	if preprocessCmd, ok := cmd.(ICommandWithPreprocess); ok {
 		remaining = preprocessCmd.Preprocess(remaining)
 	}
-	ctx, err := k.Parse(getArgs(remaining, cmd.SplitBySpaceOnly()))
+	ctx, err := parser.kong.Parse(getArgs(remaining, cmd.SplitBySpaceOnly()))
 	if err != nil {
 		parseResult.Error = err
 	}
-	parseResult.ParsedArgs = gram
+	// 只有参数解析成功时才计入频率限制，输错参数或查看帮助不会消耗冷却与次数
+	if err == nil && !exited && !c.checkRateLimit(event, cmd) {
+		return
+	}
+	cmdCtx, cancel := c.commandContext(cmd)
+	defer cancel()
+	parseResult.Context = cmdCtx
+	parseResult.ParsedArgs = copyArgs(parser.args)
 	parseResult.Ctx = ctx
-	parseResult.Event = event
-	parseResult.StdOut = stdout.String()
+	parseResult.Event = event.withReplyContext(cmdCtx)
+	parseResult.Name = name
+	parseResult.Prefix = prefix
+	if match != nil {
+		parseResult.Captures = match.captures
+	}
+	parseResult.ReplyTo = replyTo
+	parseResult.Subcommand = getSubcommand(ctx)
+	parseResult.StdOut = c.translateUsage(stdout.String(), parseResult.Locale)
 	parseResult.StdErr = stderr.String()
-	cmd.OnCommand(parseResult)
-	if cmd.(ICommandStopPropagation).StopPropagation() {
+	if c.handlesParseError(cmd, parseResult) {
+		c.sendResult(parseResult.Event, c.formatParseError(cmd, parseResult))
+	} else if resultCmd, ok := cmd.(ICommandWithResult); ok {
+		c.sendResult(parseResult.Event, resultCmd.Execute(parseResult))
+	} else {
+		cmd.OnCommand(parseResult)
+	}
+	if parseResult.Event.isDefaultPrevented() {
+		event.PreventDefault()
+	}
+	if stopCmd, ok := cmd.(ICommandStopPropagation); ok && stopCmd.StopPropagation() {
 		event.PreventDefault()
 	}
 }
 
+// commandMatch 普通指令与前缀指令的匹配结果
+type commandMatch struct {
+	cmd ICommand
+	// consumed 消息开头被消耗的部分（转义后），包括全局激活前缀
+	consumed string
+	// prefix 匹配到的全局激活前缀
+	prefix string
+	// name 匹配到的指令名称部分（转义后）
+	name string
+}
+
 func (c *CommandCenter) getCommand(raw string) (ICommand, string) {
+	m := c.matchCommand(raw, c.globalPrefixes)
+	if m == nil {
+		return nil, ""
+	}
+	return m.cmd, m.name
+}
+
+// matchCommand 使用指定的全局激活前缀匹配指令。设置了前缀时，消息必须以其中一个开头（空字符串表示可以省略前缀）。
+// 较长的前缀优先。
+func (c *CommandCenter) matchCommand(raw string, prefixes []string) *commandMatch {
 	pref := getPrefix(raw)
 	if len(pref) == 0 {
-		return nil, ""
+		return nil
+	}
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	} else {
+		prefixes = slices.Clone(prefixes)
+		slices.SortStableFunc(prefixes, func(a, b string) int { return len(b) - len(a) })
+	}
+	for _, globalPrefix := range prefixes {
+		escapedGlobal := message.EscapeCQString(globalPrefix)
+		if !strings.HasPrefix(pref, escapedGlobal) {
+			continue
+		}
+		if cmd, name := c.matchName(pref[len(escapedGlobal):]); cmd != nil {
+			return &commandMatch{cmd: cmd, consumed: escapedGlobal + name, prefix: globalPrefix, name: name}
+		}
 	}
-	if c.globalPrefix != "" && strings.HasPrefix(pref, c.globalPrefix) {
-		pref = pref[len(c.globalPrefix):]
+	return nil
+}
+
+// matchName 匹配去掉全局激活前缀后的指令名称，返回指令以及名称部分。
+func (c *CommandCenter) matchName(pref string) (ICommand, string) {
+	if len(pref) == 0 {
+		return nil, ""
 	}
 	for _, cmd := range c.PrefixCommands {
-		p, _ := cmd.GetName()
-		escapedP := message.EscapeCQString(p)
-		if strings.HasPrefix(pref, escapedP) {
-			return cmd, pref[:len(escapedP)]
+		for _, p := range commandNames(cmd) {
+			escapedP := message.EscapeCQString(p)
+			if strings.HasPrefix(pref, escapedP) {
+				return cmd, pref[:len(escapedP)]
+			}
 		}
 	}
 	cmd, ok := c.Commands[message.UnescapeCQString(pref)]
@@ -151,6 +487,30 @@ func (c *CommandCenter) getCommand(raw string) (ICommand, string) {
 	return cmd, pref
 }
 
+// commandNames 返回指令名称以及所有别名。
+func commandNames(cmd ICommand) []string {
+	name, _ := cmd.GetName()
+	names := []string{name}
+	if aliasCmd, ok := cmd.(ICommandWithAliases); ok {
+		names = append(names, aliasCmd.GetAliases()...)
+	}
+	return names
+}
+
+// getSubcommand 返回 kong 匹配到的子指令路径。
+func getSubcommand(ctx *kong.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	names := make([]string, 0, 2)
+	for _, path := range ctx.Path {
+		if path.Command != nil {
+			names = append(names, path.Command.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
 func getPrefix(raw string) string {
 	pref, _, _ := strings.Cut(raw, "[CQ:")
 	pref, _, _ = strings.Cut(pref, " ")
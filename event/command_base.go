@@ -0,0 +1,67 @@
+package event
+
+import (
+	"reflect"
+
+	"github.com/alecthomas/kong"
+	"go.uber.org/zap"
+)
+
+// CommandBase 可以嵌入到指令结构体中，提供 ICommand 中除 GetName 外方法的默认实现：
+// 没有参数，没有额外的 kong 选项，按 shell 规则分割参数，OnCommand 不做任何事（可以改为实现 ICommandWithResult）。
+//
+// 别名、描述、权限、频率限制、预处理、停止传播等可选功能通过实现对应的接口提供，指令中心使用前会检查指令是否实现了这些接口。
+type CommandBase struct{}
+
+func (CommandBase) GetNew() any {
+	return &struct{}{}
+}
+
+func (CommandBase) GetOptions() []kong.Option {
+	return nil
+}
+
+func (CommandBase) SplitBySpaceOnly() bool {
+	return false
+}
+
+func (CommandBase) OnCommand(parseResult *ParseResult) {}
+
+// commandCapability 指令的可选接口及其方法名
+type commandCapability struct {
+	method string
+	iface  reflect.Type
+}
+
+var commandCapabilities = []commandCapability{
+	{"GetAliases", reflect.TypeFor[ICommandWithAliases]()},
+	{"GetDescription", reflect.TypeFor[ICommandWithDescription]()},
+	{"Preprocess", reflect.TypeFor[ICommandWithPreprocess]()},
+	{"StopPropagation", reflect.TypeFor[ICommandStopPropagation]()},
+	{"GetPermissions", reflect.TypeFor[ICommandWithPermission]()},
+	{"GetRateLimit", reflect.TypeFor[ICommandWithRateLimit]()},
+	{"GetTimeout", reflect.TypeFor[ICommandWithTimeout]()},
+	{"Execute", reflect.TypeFor[ICommandWithResult]()},
+	{"HandlesParseError", reflect.TypeFor[ICommandHandlesParseError]()},
+}
+
+// mismatchedCapabilities 返回指令中与可选接口同名但签名不符的方法。这些方法不会被指令中心调用。
+func mismatchedCapabilities(cmd ICommand) []commandCapability {
+	t := reflect.TypeOf(cmd)
+	var res []commandCapability
+	for _, c := range commandCapabilities {
+		if _, ok := t.MethodByName(c.method); ok && !t.Implements(c.iface) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// checkCapabilities 对签名不符的可选接口方法记录警告，避免它们被静默忽略。
+func (c *CommandCenter) checkCapabilities(cmd ICommand) {
+	name, _ := cmd.GetName()
+	for _, m := range mismatchedCapabilities(cmd) {
+		c.logger.Warn("command method does not match the optional interface and will be ignored",
+			zap.String("command", name), zap.String("method", m.method), zap.String("interface", m.iface.String()))
+	}
+}
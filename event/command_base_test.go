@@ -0,0 +1,123 @@
+package event
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nekoite/go-napcat/message"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testPingCommand struct {
+	CommandBase
+}
+
+func (c *testPingCommand) GetName() (string, CmdNameMode) {
+	return "ping", CmdNameModeNormal
+}
+
+func (c *testPingCommand) Execute(parseResult *ParseResult) any {
+	return "pong"
+}
+
+type testStopPropagationCommand struct {
+	testCommand
+	stop bool
+}
+
+func (c *testStopPropagationCommand) StopPropagation() bool {
+	return c.stop
+}
+
+type testPreprocessCommand struct {
+	testCommand
+}
+
+func (c *testPreprocessCommand) Preprocess(remaining string) string {
+	return strings.ReplaceAll(remaining, "，", " ")
+}
+
+// testMismatchedCommand 的可选方法签名都不正确
+type testMismatchedCommand struct {
+	CommandBase
+}
+
+func (c *testMismatchedCommand) GetName() (string, CmdNameMode) {
+	return "bad", CmdNameModeNormal
+}
+
+func (c *testMismatchedCommand) Preprocess(remaining string) {}
+
+func (c *testMismatchedCommand) StopPropagation() {}
+
+func TestCommandBase(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.RegisterCommand(&testPingCommand{})
+	e := newTestReplyEvent("ping")
+	c.onMessageRecv(e)
+	if assert.Len(e.replies, 1) {
+		assert.Equal("pong", e.replies[0].Messages[0].Data.(*message.TextData).Text)
+	}
+	assert.False(e.isDefaultPrevented())
+}
+
+func TestStopPropagation(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.RegisterCommand(&testCommand{name: "plain", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(*ParseResult) {}})
+	c.RegisterCommand(&testStopPropagationCommand{testCommand: testCommand{name: "stop", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(*ParseResult) {}}, stop: true})
+	c.RegisterCommand(&testStopPropagationCommand{testCommand: testCommand{name: "go", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(*ParseResult) {}}, stop: false})
+
+	e := newTestPrivateMessageEvent("plain")
+	assert.NotPanics(func() { c.onMessageRecv(e) })
+	assert.False(e.isDefaultPrevented())
+
+	e = newTestPrivateMessageEvent("stop")
+	c.onMessageRecv(e)
+	assert.True(e.isDefaultPrevented())
+
+	e = newTestPrivateMessageEvent("go")
+	c.onMessageRecv(e)
+	assert.False(e.isDefaultPrevented())
+}
+
+func TestPreprocess(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	var got []string
+	c.RegisterCommand(&testPreprocessCommand{testCommand{name: "echo", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(p *ParseResult) {
+		got = p.ParsedArgs.(*testEchoArgs).Args
+	}}})
+	c.onMessageRecv(newTestPrivateMessageEvent("echo a，b"))
+	assert.Equal([]string{"a", "b"}, got)
+}
+
+func TestMismatchedCapabilities(t *testing.T) {
+	assert := assert.New(t)
+	methods := func(cmd ICommand) []string {
+		var res []string
+		for _, m := range mismatchedCapabilities(cmd) {
+			res = append(res, m.method)
+		}
+		return res
+	}
+	assert.Equal([]string{"Preprocess", "StopPropagation"}, methods(&testMismatchedCommand{}))
+	assert.Empty(methods(&testPingCommand{}))
+	assert.Empty(methods(&testPreprocessCommand{}))
+	assert.Empty(methods(&testStopPropagationCommand{}))
+	assert.Empty(methods(&testDescCommand{}))
+	assert.Empty(methods(&testRateLimitCommand{}))
+	assert.Empty(methods(NewHelpCommand(nil)))
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	c := NewCommandCenter(zap.New(core))
+	c.RegisterCommand(&testMismatchedCommand{})
+	assert.Equal(2, logs.Len())
+	e := newTestPrivateMessageEvent("bad")
+	assert.NotPanics(func() { c.onMessageRecv(e) })
+	assert.False(e.isDefaultPrevented())
+}
@@ -0,0 +1,33 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// ICommandWithTimeout 声明指令的执行时间限制。返回值大于 0 时覆盖指令中心的默认时间限制。
+type ICommandWithTimeout interface {
+	GetTimeout() time.Duration
+}
+
+// SetCommandTimeout 设置指令默认的执行时间限制，超时后 ParseResult.Context 将被取消。小于等于 0 时不限制（默认）。
+func (c *CommandCenter) SetCommandTimeout(timeout time.Duration) {
+	c.commandTimeout = timeout
+}
+
+// Close 取消所有正在执行的指令的 ParseResult.Context。之后执行的指令得到的 context 也已被取消。
+func (c *CommandCenter) Close() {
+	c.cancel()
+}
+
+// commandContext 为一次指令调用创建 context，它在超时或者指令中心关闭时被取消。
+func (c *CommandCenter) commandContext(cmd ICommand) (context.Context, context.CancelFunc) {
+	timeout := c.commandTimeout
+	if timeoutCmd, ok := cmd.(ICommandWithTimeout); ok && timeoutCmd.GetTimeout() > 0 {
+		timeout = timeoutCmd.GetTimeout()
+	}
+	if timeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, timeout)
+}
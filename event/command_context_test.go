@@ -0,0 +1,138 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nekoite/go-napcat/message"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testTimeoutCommand struct {
+	testCommand
+	timeout time.Duration
+}
+
+func (c *testTimeoutCommand) GetTimeout() time.Duration {
+	return c.timeout
+}
+
+func TestCommandTimeout(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.SetCommandTimeout(10 * time.Millisecond)
+	var errs []error
+	wait := func(p *ParseResult) {
+		<-p.Context.Done()
+		errs = append(errs, p.Context.Err())
+	}
+	assert.NoError(c.RegisterCommand(&testCommand{name: "slow", mode: CmdNameModeNormal, onCommand: wait}))
+	assert.NoError(c.RegisterCommand(&testTimeoutCommand{testCommand: testCommand{name: "slower", mode: CmdNameModeNormal, onCommand: wait}, timeout: 50 * time.Millisecond}))
+
+	start := time.Now()
+	c.onMessageRecv(newTestPrivateMessageEvent("slow"))
+	assert.Less(time.Since(start), 50*time.Millisecond)
+	start = time.Now()
+	c.onMessageRecv(newTestPrivateMessageEvent("slower"))
+	assert.GreaterOrEqual(time.Since(start), 50*time.Millisecond)
+	assert.Equal([]error{context.DeadlineExceeded, context.DeadlineExceeded}, errs)
+}
+
+func TestCommandContextNoTimeout(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	var ctx context.Context
+	assert.NoError(c.RegisterCommand(&testCommand{name: "cmd", mode: CmdNameModeNormal, onCommand: func(p *ParseResult) {
+		ctx = p.Context
+		_, ok := p.Context.Deadline()
+		assert.False(ok)
+		assert.NoError(p.Context.Err())
+	}}))
+	c.onMessageRecv(newTestPrivateMessageEvent("cmd"))
+	// 指令返回后 context 被取消
+	assert.ErrorIs(ctx.Err(), context.Canceled)
+}
+
+func TestCommandCenterClose(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	started := make(chan struct{})
+	var err error
+	assert.NoError(c.RegisterCommand(&testCommand{name: "wait", mode: CmdNameModeNormal, onCommand: func(p *ParseResult) {
+		close(started)
+		<-p.Context.Done()
+		err = p.Context.Err()
+	}}))
+	done := make(chan struct{})
+	go func() {
+		c.onMessageRecv(newTestPrivateMessageEvent("wait"))
+		close(done)
+	}()
+	<-started
+	c.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("command was not cancelled")
+	}
+	assert.ErrorIs(err, context.Canceled)
+}
+
+func TestReplyHonorsCommandContext(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.SetCommandTimeout(time.Millisecond)
+	var err error
+	assert.NoError(c.RegisterCommand(&testCommand{name: "late", mode: CmdNameModeNormal, onCommand: func(p *ParseResult) {
+		<-p.Context.Done()
+		// 超时后不会再发送回复
+		_, err = p.Event.Reply(message.NewText("too late").Segment().AsChain(), false)
+	}}))
+	e := newTestReplyEvent("late")
+	c.onMessageRecv(e)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Empty(e.replies)
+	// 原事件不受指令的 context 影响，同一事件的其它处理器仍然可以回复
+	_, err = e.Reply(message.NewText("hi").Segment().AsChain(), false)
+	assert.NoError(err)
+	assert.Len(e.replies, 1)
+}
+
+func TestResultReplyAfterTimeout(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.SetCommandTimeout(time.Millisecond)
+	assert.NoError(c.RegisterCommand(&testResultCommand{testCommand: testCommand{name: "slow", mode: CmdNameModeNormal}, execute: func(p *ParseResult) any {
+		<-p.Context.Done()
+		return p.Context.Err()
+	}}))
+	e := newTestReplyEvent("slow")
+	c.onMessageRecv(e)
+	// 超时后返回的结果不会被发送
+	assert.Empty(e.replies)
+}
+
+func TestResultReplyAfterClose(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.Close()
+	assert.NoError(c.RegisterCommand(&testResultCommand{testCommand: testCommand{name: "cmd", mode: CmdNameModeNormal}, execute: func(p *ParseResult) any {
+		return "done"
+	}}))
+	e := newTestReplyEvent("cmd")
+	c.onMessageRecv(e)
+	assert.Empty(e.replies)
+}
+
+func TestCommandPreventDefault(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	assert.NoError(c.RegisterCommand(&testCommand{name: "cmd", mode: CmdNameModeNormal, onCommand: func(p *ParseResult) {
+		p.Event.PreventDefault()
+	}}))
+	e := newTestPrivateMessageEvent("cmd")
+	c.onMessageRecv(e)
+	assert.True(e.isDefaultPrevented())
+}
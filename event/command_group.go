@@ -0,0 +1,66 @@
+package event
+
+import (
+	"github.com/alecthomas/kong"
+)
+
+// CommandGroup 由多个子指令组成的指令，例如 "admin ban"、"admin kick"。
+//
+// New 返回的参数结构体中使用 kong 的 `cmd:""` 标签定义子指令（可以使用 `aliases:""` 标签定义子指令的别名），
+// 每个子指令结构体实现 `Run(parseResult *event.ParseResult) error` 方法。匹配到的子指令的 Run 方法将被调用，
+// 匹配到的子指令路径见 [ParseResult.Subcommand]。
+type CommandGroup struct {
+	// Name 指令名称，名称模式为 [CmdNameModeNormal]
+	Name    string
+	Aliases []string
+	// Description 指令的简短描述，用于帮助指令的列表
+	Description string
+	// Permissions 执行指令需要的权限，见 [ICommandWithPermission]
+	Permissions []Permission
+	// New 返回新的参数结构体
+	New     func() any
+	Options []kong.Option
+	// OnError 在解析失败或子指令的 Run 返回错误时调用，此时 [ParseResult.Error] 不为 nil。可以为 nil
+	OnError func(parseResult *ParseResult)
+}
+
+func (g *CommandGroup) GetName() (string, CmdNameMode) {
+	return g.Name, CmdNameModeNormal
+}
+
+func (g *CommandGroup) GetAliases() []string {
+	return g.Aliases
+}
+
+func (g *CommandGroup) GetDescription() string {
+	return g.Description
+}
+
+func (g *CommandGroup) GetPermissions() []Permission {
+	return g.Permissions
+}
+
+func (g *CommandGroup) GetNew() any {
+	return g.New()
+}
+
+func (g *CommandGroup) GetOptions() []kong.Option {
+	return g.Options
+}
+
+func (g *CommandGroup) SplitBySpaceOnly() bool {
+	return false
+}
+
+func (g *CommandGroup) StopPropagation() bool {
+	return true
+}
+
+func (g *CommandGroup) OnCommand(parseResult *ParseResult) {
+	if parseResult.Error == nil {
+		parseResult.Error = parseResult.Ctx.Run(parseResult)
+	}
+	if parseResult.Error != nil && g.OnError != nil {
+		g.OnError(parseResult)
+	}
+}
@@ -0,0 +1,103 @@
+package event
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testAliasCommand struct {
+	testCommand
+	aliases []string
+}
+
+func (c *testAliasCommand) GetAliases() []string {
+	return c.aliases
+}
+
+type testAdminArgs struct {
+	Ban struct {
+		User string `arg:""`
+	} `cmd:"" aliases:"封禁" help:"ban"`
+	Kick testKickCmd `cmd:"" aliases:"踢" help:"kick"`
+}
+
+type testKickCmd struct {
+	User string `arg:""`
+}
+
+var testKicked string
+
+func (k *testKickCmd) Run(parseResult *ParseResult) error {
+	if k.User == "bad" {
+		return errors.New("cannot kick")
+	}
+	testKicked = k.User
+	return nil
+}
+
+func TestCommandAliases(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	var names []string
+	d.RegisterCommand(&testAliasCommand{
+		testCommand: testCommand{
+			name:      "music",
+			mode:      CmdNameModeNormal,
+			getNew:    func() any { return &struct{}{} },
+			onCommand: func(parseResult *ParseResult) { names = append(names, parseResult.Name) },
+		},
+		aliases: []string{"点歌", "m"},
+	})
+	d.RegisterCommand(&testAliasCommand{
+		testCommand: testCommand{
+			name:      "echo",
+			mode:      CmdNameModePrefix,
+			getNew:    func() any { return &struct{}{} },
+			onCommand: func(parseResult *ParseResult) { names = append(names, parseResult.Name) },
+		},
+		aliases: []string{"复读"},
+	})
+	for _, msg := range []string{"music", "点歌", "m", "mm", "echo", "复读机"} {
+		d.Dispatch(newTestPrivateMessageEvent(msg))
+	}
+	assert.Equal([]string{"music", "点歌", "m", "echo", "复读"}, names)
+}
+
+func TestCommandGroup(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	var subcommand string
+	var lastErr error
+	d.RegisterCommand(&CommandGroup{
+		Name:    "admin",
+		Aliases: []string{"管理"},
+		New:     func() any { return &testAdminArgs{} },
+		OnError: func(parseResult *ParseResult) {
+			subcommand = parseResult.Subcommand
+			lastErr = parseResult.Error
+		},
+	})
+
+	d.Dispatch(newTestPrivateMessageEvent("admin kick alice"))
+	assert.Equal("alice", testKicked)
+	assert.Nil(lastErr)
+
+	d.Dispatch(newTestPrivateMessageEvent("管理 踢 bob"))
+	assert.Equal("bob", testKicked)
+
+	d.Dispatch(newTestPrivateMessageEvent("admin kick bad"))
+	assert.EqualError(lastErr, "cannot kick")
+	assert.Equal("kick", subcommand)
+
+	lastErr = nil
+	d.Dispatch(newTestPrivateMessageEvent("admin 封禁 carol"))
+	assert.Error(lastErr)
+	assert.Equal("ban", subcommand)
+
+	lastErr = nil
+	d.Dispatch(newTestPrivateMessageEvent("admin"))
+	assert.Error(lastErr)
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/alecthomas/kong"
+	"github.com/nekoite/go-napcat/i18n"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -12,6 +13,7 @@ type testCommand struct {
 	name             string
 	mode             CmdNameMode
 	splitBySpaceOnly bool
+	getNew           func() any
 	onCommand        func(parseResult *ParseResult)
 }
 
@@ -20,7 +22,10 @@ func (c *testCommand) GetName() (string, CmdNameMode) {
 }
 
 func (c *testCommand) GetNew() any {
-	return nil
+	if c.getNew == nil {
+		return &struct{}{}
+	}
+	return c.getNew()
 }
 
 func (c *testCommand) GetOptions() []kong.Option {
@@ -208,3 +213,86 @@ func TestGetCommandModePrefix(t *testing.T) {
 	assert.Nil(actual)
 	assert.Equal("", pref)
 }
+
+type testEchoArgs struct {
+	Args []string `arg:"" optional:""`
+}
+
+func TestGlobalPrefixRequired(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetGlobalCommandPrefix("/")
+	var args []string
+	d.RegisterCommand(&testCommand{
+		name:      "echo",
+		mode:      CmdNameModeNormal,
+		getNew:    func() any { return &testEchoArgs{} },
+		onCommand: func(parseResult *ParseResult) { args = parseResult.ParsedArgs.(*testEchoArgs).Args },
+	})
+	d.Dispatch(newTestPrivateMessageEvent("echo a b"))
+	assert.Nil(args)
+	d.Dispatch(newTestPrivateMessageEvent("/echo a b"))
+	assert.Equal([]string{"a", "b"}, args)
+}
+
+func TestMultiplePrefixes(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetGlobalCommandPrefixes("/", "！", "!!")
+	var prefixes []string
+	var args []string
+	d.RegisterCommand(&testCommand{
+		name:   "echo",
+		mode:   CmdNameModeNormal,
+		getNew: func() any { return &testEchoArgs{} },
+		onCommand: func(parseResult *ParseResult) {
+			prefixes = append(prefixes, parseResult.Prefix)
+			args = parseResult.ParsedArgs.(*testEchoArgs).Args
+		},
+	})
+	d.RegisterCommand(&testCommand{
+		name:      "!echo",
+		mode:      CmdNameModeNormal,
+		getNew:    func() any { return &testEchoArgs{} },
+		onCommand: func(parseResult *ParseResult) { prefixes = append(prefixes, "bang:"+parseResult.Prefix) },
+	})
+	d.Dispatch(newTestGroupMessageEvent("/echo a", 10, 1))
+	assert.Equal([]string{"a"}, args)
+	d.Dispatch(newTestGroupMessageEvent("！echo b", 10, 1))
+	assert.Equal([]string{"b"}, args)
+	d.Dispatch(newTestGroupMessageEvent("!!echo", 10, 1))
+	d.Dispatch(newTestGroupMessageEvent("echo", 10, 1))
+	d.Dispatch(newTestPrivateMessageEvent("echo"))
+	assert.Equal([]string{"/", "！", "!!"}, prefixes)
+
+	d.SetPrefixRequiredInGroupOnly(true)
+	prefixes = nil
+	d.Dispatch(newTestGroupMessageEvent("echo", 10, 1))
+	d.Dispatch(newTestPrivateMessageEvent("echo c"))
+	d.Dispatch(newTestPrivateMessageEvent("/echo d"))
+	assert.Equal([]string{"", "/"}, prefixes)
+	assert.Equal([]string{"d"}, args)
+
+	// 群单独设置的前缀优先于全局前缀
+	assert.NoError(d.Scopes().SetPrefixes(GroupScope(10), "#"))
+	prefixes = nil
+	d.Dispatch(newTestGroupMessageEvent("/echo", 10, 1))
+	d.Dispatch(newTestGroupMessageEvent("#echo", 10, 1))
+	d.Dispatch(newTestGroupMessageEvent("/echo", 11, 1))
+	assert.Equal([]string{"#", "/"}, prefixes)
+	assert.NoError(d.Scopes().SetPrefixes(GroupScope(10)))
+	prefixes = nil
+	d.Dispatch(newTestGroupMessageEvent("echo", 10, 1))
+	assert.Equal([]string{""}, prefixes)
+}
+
+func TestFormatPrefixes(t *testing.T) {
+	assert := assert.New(t)
+	catalog := i18n.DefaultCatalog()
+	assert.Equal("无", formatPrefixes(catalog, "zh-CN", nil))
+	assert.Equal("无", formatPrefixes(catalog, "zh-CN", []string{""}))
+	assert.Equal("/ !", formatPrefixes(catalog, "zh-CN", []string{"/", "!"}))
+	assert.Equal("/（可省略）", formatPrefixes(catalog, "zh-CN", []string{"/", ""}))
+	assert.Equal("none", formatPrefixes(catalog, "en-US", nil))
+	assert.Equal("/ (optional)", formatPrefixes(catalog, "en-US", []string{"/", ""}))
+}
@@ -0,0 +1,50 @@
+package event
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// repeatableNotices 可以在同一秒内合法地重复出现、并且没有可以区分它们的字段的通知，它们不去重
+var repeatableNotices = map[string]bool{
+	string(NoticeEventTypeGroupMsgEmojiLike):                                    true,
+	string(NoticeEventTypeNotify) + "/" + string(NoticeEventSubtypePoke):        true,
+	string(NoticeEventTypeNotify) + "/" + string(NoticeEventSubtypeInputStatus): true,
+	string(NoticeEventTypeNotify) + "/" + string(NoticeEventSubtypeProfileLike): true,
+}
+
+// DedupKey 根据事件的原始数据计算用于去重的键。
+// 消息事件使用 (self_id, post_type, message_id)，请求事件使用 (self_id, post_type, flag)，
+// 通知事件使用 (self_id, post_type, time) 以及通知的类型与相关 ID。
+// 元事件，以及戳一戳、输入状态、资料卡点赞与群消息表情回应这些可能在同一秒内重复出现的通知不去重，此时返回 false。
+func DedupKey(data []byte) (string, bool) {
+	fields := gjson.GetManyBytes(data, "self_id", "post_type", "message_id", "flag", "time",
+		"notice_type", "sub_type", "user_id", "group_id", "operator_id", "target_id")
+	postType := EventType(fields[1].String())
+	var parts []string
+	switch postType {
+	case EventTypeMessage, EventTypeMessageSent:
+		if !fields[2].Exists() {
+			return "", false
+		}
+		parts = []string{fields[2].String()}
+	case EventTypeRequest:
+		if !fields[3].Exists() {
+			return "", false
+		}
+		parts = []string{fields[3].String()}
+	case EventTypeNotice:
+		if repeatableNotices[fields[5].String()] || repeatableNotices[fields[5].String()+"/"+fields[6].String()] {
+			return "", false
+		}
+		parts = make([]string, 0, len(fields)-4)
+		for _, f := range fields[4:] {
+			parts = append(parts, f.String())
+		}
+		parts = append(parts, fields[2].String())
+	default:
+		return "", false
+	}
+	return fields[0].String() + "|" + string(postType) + "|" + strings.Join(parts, "|"), true
+}
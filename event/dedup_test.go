@@ -0,0 +1,46 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupKey(t *testing.T) {
+	assert := assert.New(t)
+	key, ok := DedupKey([]byte(`{"self_id":1,"post_type":"message","message_type":"group","message_id":123,"time":5}`))
+	assert.True(ok)
+	assert.Equal("1|message|123", key)
+
+	key2, ok := DedupKey([]byte(`{"self_id":1,"post_type":"message","message_type":"group","message_id":123,"time":6}`))
+	assert.True(ok)
+	assert.Equal(key, key2)
+
+	key, ok = DedupKey([]byte(`{"self_id":1,"post_type":"message_sent","message_id":123}`))
+	assert.True(ok)
+	assert.Equal("1|message_sent|123", key)
+
+	key, ok = DedupKey([]byte(`{"self_id":1,"post_type":"request","request_type":"friend","flag":"abc"}`))
+	assert.True(ok)
+	assert.Equal("1|request|abc", key)
+
+	key, ok = DedupKey([]byte(`{"self_id":1,"post_type":"notice","notice_type":"group_ban","sub_type":"ban","time":5,"user_id":2,"group_id":3,"operator_id":4}`))
+	assert.True(ok)
+	key2, _ = DedupKey([]byte(`{"self_id":1,"post_type":"notice","notice_type":"group_ban","sub_type":"ban","time":5,"user_id":5,"group_id":3,"operator_id":4}`))
+	assert.NotEqual(key, key2)
+
+	// 同一秒内可能重复出现的通知不去重
+	_, ok = DedupKey([]byte(`{"self_id":1,"post_type":"notice","notice_type":"notify","sub_type":"poke","time":5,"user_id":2,"group_id":3,"target_id":6}`))
+	assert.False(ok)
+	_, ok = DedupKey([]byte(`{"self_id":1,"post_type":"notice","notice_type":"notify","sub_type":"input_status","time":5,"user_id":2}`))
+	assert.False(ok)
+	_, ok = DedupKey([]byte(`{"self_id":1,"post_type":"notice","notice_type":"group_msg_emoji_like","time":5,"user_id":2,"group_id":3,"message_id":8}`))
+	assert.False(ok)
+	_, ok = DedupKey([]byte(`{"self_id":1,"post_type":"notice","notice_type":"notify","sub_type":"honor","time":5,"user_id":2,"group_id":3}`))
+	assert.True(ok)
+
+	_, ok = DedupKey([]byte(`{"self_id":1,"post_type":"meta_event","meta_event_type":"heartbeat","time":5}`))
+	assert.False(ok)
+	_, ok = DedupKey([]byte(`{"self_id":1,"post_type":"message"}`))
+	assert.False(ok)
+}
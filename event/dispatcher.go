@@ -1,6 +1,11 @@
 package event
 
 import (
+	"runtime/debug"
+	"time"
+
+	"github.com/nekoite/go-napcat/i18n"
+	"github.com/nekoite/go-napcat/message"
 	"go.uber.org/zap"
 )
 
@@ -8,10 +13,17 @@ import (
 
 type Handler func(event IEvent)
 
+// Interceptor 在指令与处理器之前处理消息事件。返回 true 表示事件已被消费，不再继续传播。
+type Interceptor func(event IMessageEvent) bool
+
+// PanicHandler 在处理器或指令发生 panic 并被恢复后调用。recovered 为 recover() 的返回值，stack 为发生 panic 时的调用栈。
+type PanicHandler func(event IEvent, recovered any, stack []byte)
+
 type handlersByType struct {
 	all            []Handler
 	groupMessage   []Handler
 	privateMessage []Handler
+	messageSent    []Handler
 	notice         []Handler
 	meta           []Handler
 	request        []Handler
@@ -22,6 +34,12 @@ type Dispatcher struct {
 	isGoroutineMode bool
 	handlers        handlersByType
 	commandCenter   *CommandCenter
+	pool            *WorkerPool
+	waiters         waiterList
+	interceptors    []Interceptor
+
+	onPanic    PanicHandler
+	panicReply string
 }
 
 func NewDispatcher(logger *zap.Logger, isGoroutineMode bool) *Dispatcher {
@@ -45,10 +63,25 @@ func (d *Dispatcher) RegisterHandlerPrivateMessage(handler Handler) {
 	d.handlers.privateMessage = append(d.handlers.privateMessage, handler)
 }
 
+// RegisterHandlerMessageSent 注册处理机器人自己发送的消息（message_sent）的处理器。
+// 这类事件不会触发指令、拦截器与普通消息处理器。
+func (d *Dispatcher) RegisterHandlerMessageSent(handler Handler) {
+	d.handlers.messageSent = append(d.handlers.messageSent, handler)
+}
+
 func (d *Dispatcher) RegisterHandlerNotice(handler Handler) {
 	d.handlers.notice = append(d.handlers.notice, handler)
 }
 
+// RegisterHandlerNoticeType 注册只处理 noticeType 类型通知事件的处理器。
+func (d *Dispatcher) RegisterHandlerNoticeType(noticeType NoticeEventType, handler Handler) {
+	d.RegisterHandlerNotice(func(event IEvent) {
+		if e, ok := event.(INoticeEvent); ok && e.GetNoticeType() == noticeType {
+			handler(event)
+		}
+	})
+}
+
 func (d *Dispatcher) RegisterHandlerMeta(handler Handler) {
 	d.handlers.meta = append(d.handlers.meta, handler)
 }
@@ -57,36 +90,164 @@ func (d *Dispatcher) RegisterHandlerRequest(handler Handler) {
 	d.handlers.request = append(d.handlers.request, handler)
 }
 
-func (d *Dispatcher) RegisterCommand(command ICommand) {
-	d.commandCenter.RegisterCommand(command)
+// RegisterInterceptor 注册消息拦截器。拦截器按注册顺序在指令之前执行，与处理器一样受分发模式影响。
+func (d *Dispatcher) RegisterInterceptor(interceptor Interceptor) {
+	d.interceptors = append(d.interceptors, interceptor)
+}
+
+// RegisterCommand 注册指令，见 [CommandCenter.RegisterCommand]。
+func (d *Dispatcher) RegisterCommand(command ICommand) error {
+	return d.commandCenter.RegisterCommand(command)
 }
 
 func (d *Dispatcher) SetGlobalCommandPrefix(prefix string) {
 	d.commandCenter.SetGlobalCommandPrefix(prefix)
 }
 
+// SetGlobalCommandPrefixes 设置多个全局激活前缀，见 [CommandCenter.SetGlobalCommandPrefixes]。
+func (d *Dispatcher) SetGlobalCommandPrefixes(prefixes ...string) {
+	d.commandCenter.SetGlobalCommandPrefixes(prefixes...)
+}
+
+// SetPrefixRequiredInGroupOnly 设置为 true 时只在群聊中需要全局激活前缀，私聊中可以省略。
+func (d *Dispatcher) SetPrefixRequiredInGroupOnly(groupOnly bool) {
+	d.commandCenter.SetPrefixRequiredInGroupOnly(groupOnly)
+}
+
+// SetCommandMentionMode 设置指令是否需要 @ 机器人才能触发。
+func (d *Dispatcher) SetCommandMentionMode(mode MentionMode) {
+	d.commandCenter.SetMentionMode(mode)
+}
+
+// Scopes 返回管理每个群和私聊中功能开关与设置的范围管理器。
+func (d *Dispatcher) Scopes() *ScopeManager {
+	return d.commandCenter.Scopes()
+}
+
+// SetScopeManager 使用指定的范围管理器，例如使用持久化存储的管理器。
+func (d *Dispatcher) SetScopeManager(m *ScopeManager) {
+	d.commandCenter.SetScopeManager(m)
+}
+
+// Scoped 为处理器指定功能名称。处理器只在功能启用的范围中被调用，见 [ScopeManager]。
+func (d *Dispatcher) Scoped(feature string, h Handler) Handler {
+	return func(event IEvent) {
+		if d.commandCenter.Scopes().IsEnabledFor(event, feature) {
+			h(event)
+		}
+	}
+}
+
+// NewScopeCommand 创建管理当前聊天的指令开关与设置的内置指令，需要使用 RegisterCommand 注册。
+func (d *Dispatcher) NewScopeCommand() ICommand {
+	return NewScopeCommand(d.commandCenter)
+}
+
+// NewHelpCommand 创建列出本分发器中的指令的帮助指令，需要使用 RegisterCommand 注册。
+func (d *Dispatcher) NewHelpCommand() *HelpCommand {
+	return NewHelpCommand(d.commandCenter)
+}
+
+// Permissions 返回指令使用的权限管理器。
+func (d *Dispatcher) Permissions() *PermissionManager {
+	return d.commandCenter.Permissions()
+}
+
+// SetPermissionDeniedReply 设置没有权限执行指令时回复的内容，可以是消息目录中的键，例如 command.permission_denied。为空字符串时不回复（默认）。
+func (d *Dispatcher) SetPermissionDeniedReply(reply string) {
+	d.commandCenter.SetPermissionDeniedReply(reply)
+}
+
+// SetRateLimitReply 设置指令冷却中和今日次数用完时回复的内容，见 [CommandCenter.SetRateLimitReply]。
+func (d *Dispatcher) SetRateLimitReply(cooldown string, quota string) {
+	d.commandCenter.SetRateLimitReply(cooldown, quota)
+}
+
+// SetReplyPolicy 设置参数解析错误和指令返回值的自动回复方式，见 [ReplyPolicy]。
+func (d *Dispatcher) SetReplyPolicy(policy ReplyPolicy) {
+	d.commandCenter.SetReplyPolicy(policy)
+}
+
+// SetCommandTimeout 设置指令默认的执行时间限制，见 [CommandCenter.SetCommandTimeout]。
+func (d *Dispatcher) SetCommandTimeout(timeout time.Duration) {
+	d.commandCenter.SetCommandTimeout(timeout)
+}
+
+// Catalog 返回库生成的消息使用的消息目录。
+func (d *Dispatcher) Catalog() *i18n.Catalog {
+	return d.commandCenter.Catalog()
+}
+
+// SetDefaultLocale 设置库生成的消息默认使用的语言，见 [CommandCenter.SetDefaultLocale]。
+func (d *Dispatcher) SetDefaultLocale(locale string) {
+	d.commandCenter.SetDefaultLocale(locale)
+}
+
+// SetOnHandlerPanic 设置处理器或指令 panic 时的回调函数。
+func (d *Dispatcher) SetOnHandlerPanic(handler PanicHandler) {
+	d.onPanic = handler
+}
+
+// SetPanicReply 设置处理消息事件时发生 panic 后回复给消息来源的内容，可以是消息目录中的键，例如 command.panic。为空字符串时不回复（默认）。
+func (d *Dispatcher) SetPanicReply(reply string) {
+	d.panicReply = reply
+}
+
+// SetWorkerPool 使用工作池分发事件。同一会话（见 [ConversationKey]）中的事件将被依次按顺序处理，
+// 不同会话的事件并行处理。此时处理器总是在工作协程中依次执行，[IEvent.PreventDefault] 有效。
+func (d *Dispatcher) SetWorkerPool(pool *WorkerPool) {
+	d.pool = pool
+	if pool != nil {
+		d.isGoroutineMode = false
+	}
+}
+
+// IsAsync 返回 Dispatch 是否会在处理完成前返回。
+func (d *Dispatcher) IsAsync() bool {
+	return d.pool != nil || d.isGoroutineMode
+}
+
+// Close 关闭工作池（如果有），并等待已提交的事件处理完毕。
+func (d *Dispatcher) Close() {
+	// 先取消正在执行的指令，以便工作池中的处理器尽快返回
+	d.commandCenter.Close()
+	if d.pool != nil {
+		d.pool.Close()
+	}
+}
+
 func (d *Dispatcher) Dispatch(event IEvent) {
+	// 等待者需要在进入工作池之前处理，否则在同一会话中等待的处理器会阻塞自己等待的消息
+	if event.GetEventType() == EventTypeMessage && d.waiters.deliver(event.(IMessageEvent)) {
+		return
+	}
+	if d.pool != nil {
+		d.pool.Submit(ConversationKey(event), func() { d.dispatch(event) })
+		return
+	}
+	d.dispatch(event)
+}
+
+func (d *Dispatcher) dispatch(event IEvent) {
 	if event.GetEventType() == EventTypeMessage {
 		e := event.(IMessageEvent)
+		if d.runInterceptors(e) {
+			return
+		}
 		if d.isGoroutineMode {
-			go d.commandCenter.onMessageRecv(e)
+			// 指令中心会复制事件，先在这里复制，避免与同时执行的其它处理器竞争
+			snapshot := e.withReplyContext(nil)
+			go d.safeCall(event, func() { d.commandCenter.onMessageRecv(snapshot) })
 		} else {
-			d.commandCenter.onMessageRecv(e)
+			d.safeCall(event, func() { d.commandCenter.onMessageRecv(e) })
 			if e.isDefaultPrevented() {
 				return
 			}
 		}
 	}
 
-	for _, handler := range d.handlers.all {
-		if d.isGoroutineMode {
-			go handler(event)
-			continue
-		}
-		handler(event)
-		if event.isDefaultPrevented() {
-			return
-		}
+	if d.runHandlers(d.handlers.all, event) {
+		return
 	}
 
 	switch event.GetEventType() {
@@ -94,60 +255,77 @@ func (d *Dispatcher) Dispatch(event IEvent) {
 		e := event.(IMessageEvent)
 		met := e.GetMessageEventType()
 		if met == MessageEventTypePrivate {
-			for _, handler := range d.handlers.privateMessage {
-				if d.isGoroutineMode {
-					go handler(event)
-					continue
-				}
-				handler(event)
-				if event.isDefaultPrevented() {
-					return
-				}
-			}
+			d.runHandlers(d.handlers.privateMessage, event)
 		} else if met == MessageEventTypeGroup {
-			for _, handler := range d.handlers.groupMessage {
-				if d.isGoroutineMode {
-					go handler(event)
-					continue
-				}
-				handler(event)
-				if event.isDefaultPrevented() {
-					return
-				}
-			}
+			d.runHandlers(d.handlers.groupMessage, event)
 		}
+	case EventTypeMessageSent:
+		d.runHandlers(d.handlers.messageSent, event)
 	case EventTypeNotice:
-		for _, handler := range d.handlers.notice {
-			if d.isGoroutineMode {
-				go handler(event)
-				continue
-			}
-			handler(event)
-			if event.isDefaultPrevented() {
-				return
-			}
-		}
+		d.runHandlers(d.handlers.notice, event)
 	case EventTypeMeta:
-		for _, handler := range d.handlers.meta {
-			if d.isGoroutineMode {
-				go handler(event)
-				continue
-			}
-			handler(event)
-			if event.isDefaultPrevented() {
-				return
-			}
-		}
+		d.runHandlers(d.handlers.meta, event)
 	case EventTypeRequest:
-		for _, handler := range d.handlers.request {
-			if d.isGoroutineMode {
-				go handler(event)
-				continue
-			}
-			handler(event)
-			if event.isDefaultPrevented() {
-				return
-			}
+		d.runHandlers(d.handlers.request, event)
+	}
+}
+
+// runInterceptors 依次执行拦截器，返回事件是否被消费。
+func (d *Dispatcher) runInterceptors(event IMessageEvent) bool {
+	consumed := false
+	for _, interceptor := range d.interceptors {
+		d.safeCall(event, func() { consumed = interceptor(event) })
+		if consumed {
+			return true
 		}
 	}
+	return false
+}
+
+// runHandlers 依次执行处理器，返回事件是否被阻止继续传播。
+func (d *Dispatcher) runHandlers(handlers []Handler, event IEvent) bool {
+	for _, handler := range handlers {
+		if d.isGoroutineMode {
+			go d.safeCall(event, func() { handler(event) })
+			continue
+		}
+		d.safeCall(event, func() { handler(event) })
+		if event.isDefaultPrevented() {
+			return true
+		}
+	}
+	return false
+}
+
+// safeCall 执行 f 并恢复其中的 panic，防止单个处理器导致整个进程崩溃。
+func (d *Dispatcher) safeCall(event IEvent, f func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		stack := debug.Stack()
+		d.logger.Error("handler panic", zap.Any("panic", r), zap.Any("event", event), zap.ByteString("stack", stack))
+		if d.onPanic != nil {
+			d.safeCallNoRecover(func() { d.onPanic(event, r, stack) })
+		}
+		if e, ok := event.(IMessageEvent); ok && d.panicReply != "" {
+			d.safeCallNoRecover(func() {
+				if _, err := e.Reply(message.NewText(d.commandCenter.Translate(e, d.panicReply, nil)).Segment().AsChain(), true); err != nil {
+					d.logger.Error("failed to send panic reply", zap.Error(err))
+				}
+			})
+		}
+	}()
+	f()
+}
+
+// safeCallNoRecover 执行 f，仅记录其中的 panic 而不做其它处理。用于 panic 恢复过程中的回调。
+func (d *Dispatcher) safeCallNoRecover(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("panic during panic recovery", zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+	f()
 }
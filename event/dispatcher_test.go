@@ -0,0 +1,142 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestPrivateMessageEvent(raw string) *PrivateMessageEvent {
+	e := &PrivateMessageEvent{}
+	e.EventType = EventTypeMessage
+	e.MessageType = MessageEventTypePrivate
+	e.RawMessage = raw
+	return e
+}
+
+func TestDispatchRecoversHandlerPanic(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	var recovered any
+	var stack []byte
+	d.SetOnHandlerPanic(func(event IEvent, r any, s []byte) {
+		recovered = r
+		stack = s
+	})
+	called := false
+	d.RegisterHandlerAllTypes(func(event IEvent) {
+		panic("boom")
+	})
+	d.RegisterHandlerPrivateMessage(func(event IEvent) {
+		called = true
+	})
+	assert.NotPanics(func() {
+		d.Dispatch(newTestPrivateMessageEvent("hello"))
+	})
+	assert.Equal("boom", recovered)
+	assert.NotEmpty(stack)
+	assert.True(called)
+}
+
+func TestDispatchRecoversCommandPanic(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	panicked := 0
+	d.SetOnHandlerPanic(func(event IEvent, r any, s []byte) {
+		panicked++
+	})
+	called := false
+	d.RegisterCommand(&testCommand{
+		name:   "cmd",
+		mode:   CmdNameModeNormal,
+		getNew: func() any { return &struct{}{} },
+		onCommand: func(parseResult *ParseResult) {
+			panic("boom")
+		},
+	})
+	d.RegisterHandlerPrivateMessage(func(event IEvent) {
+		called = true
+	})
+	assert.NotPanics(func() {
+		d.Dispatch(newTestPrivateMessageEvent("cmd"))
+	})
+	assert.Equal(1, panicked)
+	assert.True(called)
+}
+
+func TestDispatchRecoversPanicHookPanic(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetOnHandlerPanic(func(event IEvent, r any, s []byte) {
+		panic("boom again")
+	})
+	d.SetPanicReply("sorry")
+	d.RegisterHandlerAllTypes(func(event IEvent) {
+		panic("boom")
+	})
+	assert.NotPanics(func() {
+		d.Dispatch(newTestPrivateMessageEvent("hello"))
+	})
+}
+
+func TestDispatchPanicReplyTranslated(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetPanicReply("command.panic")
+	d.RegisterHandlerAllTypes(func(event IEvent) {
+		panic("boom")
+	})
+	e := newTestReplyEvent("hello")
+	d.Dispatch(e)
+	d.SetDefaultLocale("en-US")
+	d.Dispatch(e)
+	if assert.Len(e.replies, 2) {
+		assert.Equal("出错了，请稍后再试", e.replies[0].FirstText().Text)
+		assert.Equal("Something went wrong, please try again later", e.replies[1].FirstText().Text)
+	}
+}
+
+func TestDispatchMessageSent(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	commandCalled := false
+	d.RegisterCommand(&testCommand{
+		name:      "cmd",
+		mode:      CmdNameModeNormal,
+		getNew:    func() any { return &struct{}{} },
+		onCommand: func(parseResult *ParseResult) { commandCalled = true },
+	})
+	var sent, private []IEvent
+	d.RegisterHandlerMessageSent(func(event IEvent) { sent = append(sent, event) })
+	d.RegisterHandlerPrivateMessage(func(event IEvent) { private = append(private, event) })
+
+	e, err := ParseEvent([]byte(`{"self_id":1,"post_type":"message_sent","message_type":"private","user_id":1,"target_id":2,"message":[],"raw_message":"cmd"}`), nil)
+	assert.Nil(err)
+	se := GetAs[PrivateMessageSentEvent](e)
+	assert.NotNil(se)
+	assert.EqualValues(2, se.TargetId)
+	assert.True(se.IsFromSelf())
+	assert.Implements((*IMessageSentEvent)(nil), e)
+	d.Dispatch(e)
+	assert.False(commandCalled)
+	assert.Equal([]IEvent{e}, sent)
+	assert.Empty(private)
+}
+
+func TestCommandIgnoresSelfMessage(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	commandCalled := false
+	d.RegisterCommand(&testCommand{
+		name:      "cmd",
+		mode:      CmdNameModeNormal,
+		getNew:    func() any { return &struct{}{} },
+		onCommand: func(parseResult *ParseResult) { commandCalled = true },
+	})
+	e := newTestPrivateMessageEvent("cmd")
+	e.SelfId = 1
+	e.UserId = 1
+	d.Dispatch(e)
+	assert.False(commandCalled)
+}
@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -103,19 +104,56 @@ type IEvent interface {
 	SetContext(any)
 	Context() any
 
+	// RawJSON 返回事件的原始 JSON 数据。手动构造的事件返回 nil
+	RawJSON() []byte
+
 	isDefaultPrevented() bool
+	getApiSender() *api.Sender
 	setApiSender(*api.Sender)
 	setError(error)
+	setRaw([]byte)
 }
 
 type IMessageEvent interface {
 	IEvent
 	GetMessageEventType() MessageEventType
 	GetMessageId() qq.MessageId
+	GetUserId() qq.UserId
 	GetMessage() *message.Chain
 	GetRawMessage() string
 
+	// Reply 回复消息。指令中的 ParseResult.Event 在指令超时或机器人关闭后不再发送，并返回 ParseResult.Context.Err()
 	Reply(msg *message.Chain, quote bool) (qq.MessageId, error)
+	// ReplyContext 与 Reply 相同，但 ctx 被取消后不再发送，并返回 ctx.Err()
+	ReplyContext(ctx context.Context, msg *message.Chain, quote bool) (qq.MessageId, error)
+
+	// withReplyContext 返回事件的副本，副本的 Reply 等函数使用 ctx 发送消息。ctx 为 nil 时使用 context.Background()
+	withReplyContext(ctx context.Context) IMessageEvent
+}
+
+// IMessageSentEvent 机器人自己发送的消息事件
+type IMessageSentEvent interface {
+	IMessageEvent
+	isMessageSent()
+}
+
+// IUserEvent 与某个用户相关的事件
+type IUserEvent interface {
+	IEvent
+	GetUserId() qq.UserId
+}
+
+// INoticeEvent 通知事件
+type INoticeEvent interface {
+	IEvent
+	GetNoticeType() NoticeEventType
+	GetNoticeSubtype() NoticeEventSubtype
+}
+
+// IGroupEvent 发生在某个群中的事件
+type IGroupEvent interface {
+	IEvent
+	GetGroupId() qq.GroupId
 }
 
 type BaseEvent struct {
@@ -123,10 +161,13 @@ type BaseEvent struct {
 	SelfId    qq.UserId `json:"self_id"`
 	EventType EventType `json:"post_type"`
 
-	context     any         `json:"-"`
-	isPrevented bool        `json:"-"`
-	apiSender   *api.Sender `json:"-"`
-	error       error       `json:"-"`
+	context any `json:"-"`
+	// replyCtx 回复消息时使用的 context，只在 withReplyContext 返回的副本中设置
+	replyCtx    context.Context `json:"-"`
+	isPrevented bool            `json:"-"`
+	apiSender   *api.Sender     `json:"-"`
+	error       error           `json:"-"`
+	raw         []byte          `json:"-"`
 }
 
 func (e *BaseEvent) GetTime() int64 {
@@ -157,10 +198,31 @@ func (e *BaseEvent) Context() any {
 	return e.context
 }
 
+func (e *BaseEvent) RawJSON() []byte {
+	return e.raw
+}
+
+// RawField 使用 gjson 语法读取原始 JSON 数据中的字段，可用于读取事件结构体中没有的字段。
+func (e *BaseEvent) RawField(path string) gjson.Result {
+	return gjson.GetBytes(e.raw, path)
+}
+
 func (e *BaseEvent) isDefaultPrevented() bool {
 	return e.isPrevented
 }
 
+func (e *BaseEvent) getApiSender() *api.Sender {
+	return e.apiSender
+}
+
+// replyContext 返回回复消息时使用的 context，没有设置时为 context.Background()。
+func (e *BaseEvent) replyContext() context.Context {
+	if e.replyCtx == nil {
+		return context.Background()
+	}
+	return e.replyCtx
+}
+
 func (e *BaseEvent) setApiSender(s *api.Sender) {
 	e.apiSender = s
 }
@@ -169,6 +231,18 @@ func (e *BaseEvent) setError(err error) {
 	e.error = err
 }
 
+func (e *BaseEvent) setRaw(raw []byte) {
+	e.raw = raw
+}
+
+// quickOpContext 返回快速操作的上下文。优先使用事件的原始数据，没有原始数据时（例如手动构造的事件）使用 fallback 序列化的结果。
+func (e *BaseEvent) quickOpContext(fallback any) any {
+	if e.raw != nil {
+		return json.RawMessage(e.raw)
+	}
+	return fallback
+}
+
 type MessageEvent struct {
 	BaseEvent
 	MessageType MessageEventType    `json:"message_type"`
@@ -192,6 +266,18 @@ type GroupMessageEvent struct {
 	Sender    qq.GroupUser     `json:"sender"`
 }
 
+// PrivateMessageSentEvent 机器人自己发送的私聊消息（post_type 为 message_sent）
+type PrivateMessageSentEvent struct {
+	PrivateMessageEvent
+	// TargetId 消息的接收者【NapCat 扩展】
+	TargetId qq.UserId `json:"target_id"`
+}
+
+// GroupMessageSentEvent 机器人自己发送的群消息（post_type 为 message_sent）
+type GroupMessageSentEvent struct {
+	GroupMessageEvent
+}
+
 type NoticeEvent struct {
 	BaseEvent
 	NoticeType NoticeEventType    `json:"notice_type"`
@@ -262,6 +348,13 @@ type GroupRequestEvent struct {
 	GroupId qq.GroupId          `json:"group_id"`
 }
 
+// UnknownEvent 未知上报类型的事件。可以使用 RawJSON 或 RawField 读取原始数据
+type UnknownEvent struct {
+	BaseEvent
+	DetailType string `json:"-"`
+	SubType    string `json:"sub_type"`
+}
+
 type MetaEvent struct {
 	BaseEvent
 	MetaEventType MetaEventType     `json:"meta_event_type"`
@@ -270,6 +363,67 @@ type MetaEvent struct {
 	Interval      int64             `json:"interval"`
 }
 
+func (e *PrivateMessageSentEvent) isMessageSent() {}
+
+func (e *GroupMessageSentEvent) isMessageSent() {}
+
+// IsFromSelf 返回消息是否由机器人自己发送
+func (e *MessageEvent) IsFromSelf() bool {
+	return isFromSelf(e)
+}
+
+func isFromSelf(e IMessageEvent) bool {
+	return e.GetSelfId() != 0 && e.GetUserId() == e.GetSelfId()
+}
+
+func (e *MessageEvent) GetUserId() qq.UserId {
+	return e.UserId
+}
+
+func (e *GroupMessageEvent) GetGroupId() qq.GroupId {
+	return e.GroupId
+}
+
+func (e *NoticeEvent) GetUserId() qq.UserId {
+	return e.UserId
+}
+
+func (e *NoticeEvent) GetNoticeType() NoticeEventType {
+	return e.NoticeType
+}
+
+func (e *NoticeEvent) GetNoticeSubtype() NoticeEventSubtype {
+	return e.SubType
+}
+
+func (e *NoticeEventFriendAdd) GetUserId() qq.UserId {
+	return e.UserId
+}
+
+func (e *NoticeEventFriendAdd) GetNoticeType() NoticeEventType {
+	return e.NoticeType
+}
+
+func (e *NoticeEventFriendAdd) GetNoticeSubtype() NoticeEventSubtype {
+	return e.SubType
+}
+
+func (e *GroupNoticeEvent) GetGroupId() qq.GroupId {
+	return e.GroupId
+}
+
+func (e *RequestEvent) GetUserId() qq.UserId {
+	return e.UserId
+}
+
+func (e *FriendRequestEvent) GetUserId() qq.UserId {
+	return e.UserId
+}
+
+func (e *GroupRequestEvent) GetGroupId() qq.GroupId {
+	return e.GroupId
+}
+
 func (e *MessageEvent) GetMessageEventType() MessageEventType {
 	return e.MessageType
 }
@@ -290,12 +444,49 @@ func (e *MessageEvent) Reply(msg *message.Chain, quote bool) (qq.MessageId, erro
 	return 0, errors.ErrUnsupportedOperation
 }
 
+func (e *MessageEvent) ReplyContext(ctx context.Context, msg *message.Chain, quote bool) (qq.MessageId, error) {
+	return 0, errors.ErrUnsupportedOperation
+}
+
+func (e *MessageEvent) withReplyContext(ctx context.Context) IMessageEvent {
+	c := *e
+	c.replyCtx = ctx
+	return &c
+}
+
+func (e *PrivateMessageEvent) withReplyContext(ctx context.Context) IMessageEvent {
+	c := *e
+	c.replyCtx = ctx
+	return &c
+}
+
+func (e *GroupMessageEvent) withReplyContext(ctx context.Context) IMessageEvent {
+	c := *e
+	c.replyCtx = ctx
+	return &c
+}
+
+func (e *PrivateMessageSentEvent) withReplyContext(ctx context.Context) IMessageEvent {
+	c := *e
+	c.replyCtx = ctx
+	return &c
+}
+
+func (e *GroupMessageSentEvent) withReplyContext(ctx context.Context) IMessageEvent {
+	c := *e
+	c.replyCtx = ctx
+	return &c
+}
+
 func (e *PrivateMessageEvent) Reply(msg *message.Chain, quote bool) (qq.MessageId, error) {
+	return e.ReplyContext(e.replyContext(), msg, quote)
+}
+
+func (e *PrivateMessageEvent) ReplyContext(ctx context.Context, msg *message.Chain, quote bool) (qq.MessageId, error) {
 	if quote {
 		msg.SetReplyTo(e.MessageId)
 	}
-	// todo: use quick op?
-	resp, err := e.apiSender.SendPrivateMsg(e.UserId, msg)
+	resp, err := e.apiSender.SendPrivateMsgContext(ctx, e.UserId, msg)
 	if err != nil {
 		return 0, err
 	}
@@ -306,7 +497,7 @@ func (e *PrivateMessageEvent) ReplyString(msg string, autoEscape bool, quote boo
 	if !autoEscape && quote {
 		msg = fmt.Sprintf("[CQ:reply,id=%d]%s", e.MessageId, msg)
 	}
-	resp, err := e.apiSender.SendPrivateMsgString(e.UserId, msg, autoEscape)
+	resp, err := e.apiSender.SendPrivateMsgStringContext(e.replyContext(), e.UserId, msg, autoEscape)
 	if err != nil {
 		return 0, err
 	}
@@ -314,10 +505,14 @@ func (e *PrivateMessageEvent) ReplyString(msg string, autoEscape bool, quote boo
 }
 
 func (e *GroupMessageEvent) Reply(msg *message.Chain, quote bool) (qq.MessageId, error) {
+	return e.ReplyContext(e.replyContext(), msg, quote)
+}
+
+func (e *GroupMessageEvent) ReplyContext(ctx context.Context, msg *message.Chain, quote bool) (qq.MessageId, error) {
 	if quote {
 		msg.SetReplyTo(e.MessageId)
 	}
-	resp, err := e.apiSender.SendGroupMsg(e.GroupId, msg)
+	resp, err := e.apiSender.SendGroupMsgContext(ctx, e.GroupId, msg)
 	if err != nil {
 		return 0, err
 	}
@@ -331,7 +526,7 @@ func (e *GroupMessageEvent) ReplyAt(msg *message.Chain, quote bool, at bool) (qq
 	if at {
 		msg.PrependSegment(message.NewAtUser(e.Sender.UserId).Segment())
 	}
-	resp, err := e.apiSender.SendGroupMsg(e.GroupId, msg)
+	resp, err := e.apiSender.SendGroupMsgContext(e.replyContext(), e.GroupId, msg)
 	if err != nil {
 		return 0, err
 	}
@@ -345,13 +540,78 @@ func (e *GroupMessageEvent) ReplyString(msg string, autoEscape bool, quote bool,
 	if !autoEscape && at {
 		msg = fmt.Sprintf("[CQ:at,id=%d]%s", e.Sender.UserId, msg)
 	}
-	resp, err := e.apiSender.SendGroupMsgString(e.GroupId, msg, autoEscape)
+	resp, err := e.apiSender.SendGroupMsgStringContext(e.replyContext(), e.GroupId, msg, autoEscape)
 	if err != nil {
 		return 0, err
 	}
 	return resp.Data.MessageId, nil
 }
 
+// QuickOperation 使用快速操作处理私聊消息。只有 Reply 与 AutoEscape 有效。
+func (e *PrivateMessageEvent) QuickOperation(op *api.MessageQuickOperation) error {
+	return e.apiSender.QuickOpMessage(e.quickOpContext(e), op)
+}
+
+// QuickReply 使用快速操作回复私聊消息。与 [PrivateMessageEvent.Reply] 不同，它无法获取回复的消息 ID。
+func (e *PrivateMessageEvent) QuickReply(msg *message.Chain) error {
+	return e.QuickOperation(&api.MessageQuickOperation{Reply: msg})
+}
+
+// QuickOperation 使用快速操作处理群消息。
+func (e *GroupMessageEvent) QuickOperation(op *api.MessageQuickOperation) error {
+	return e.apiSender.QuickOpMessage(e.quickOpContext(e), op)
+}
+
+// QuickReply 使用快速操作回复群消息，atSender 为是否在开头 @ 发送者。与 [GroupMessageEvent.Reply] 不同，它无法获取回复的消息 ID。
+func (e *GroupMessageEvent) QuickReply(msg *message.Chain, atSender bool) error {
+	return e.QuickOperation(&api.MessageQuickOperation{Reply: msg, AtSender: atSender})
+}
+
+// QuickDelete 使用快速操作撤回该消息
+func (e *GroupMessageEvent) QuickDelete() error {
+	return e.QuickOperation(&api.MessageQuickOperation{Delete: true})
+}
+
+// QuickKick 使用快速操作把发送者踢出群组
+func (e *GroupMessageEvent) QuickKick() error {
+	return e.QuickOperation(&api.MessageQuickOperation{Kick: true})
+}
+
+// QuickBan 使用快速操作禁言发送者，duration 为禁言时长（秒）
+func (e *GroupMessageEvent) QuickBan(duration int) error {
+	return e.QuickOperation(&api.MessageQuickOperation{Ban: true, BanDuration: duration})
+}
+
+// QuickOperation 使用快速操作处理好友请求
+func (e *FriendRequestEvent) QuickOperation(op *api.FriendRequestQuickOperation) error {
+	return e.apiSender.QuickOpFriendRequest(e.quickOpContext(e), op)
+}
+
+// QuickApprove 使用快速操作同意好友请求，remark 为好友备注
+func (e *FriendRequestEvent) QuickApprove(remark string) error {
+	return e.QuickOperation(&api.FriendRequestQuickOperation{Approve: true, Remark: remark})
+}
+
+// QuickReject 使用快速操作拒绝好友请求
+func (e *FriendRequestEvent) QuickReject() error {
+	return e.QuickOperation(&api.FriendRequestQuickOperation{Approve: false})
+}
+
+// QuickOperation 使用快速操作处理加群请求
+func (e *GroupRequestEvent) QuickOperation(op *api.GroupRequestQuickOperation) error {
+	return e.apiSender.QuickOpGroupRequest(e.quickOpContext(e), op)
+}
+
+// QuickApprove 使用快速操作同意加群请求
+func (e *GroupRequestEvent) QuickApprove() error {
+	return e.QuickOperation(&api.GroupRequestQuickOperation{Approve: true})
+}
+
+// QuickReject 使用快速操作拒绝加群请求，reason 为拒绝理由
+func (e *GroupRequestEvent) QuickReject(reason string) error {
+	return e.QuickOperation(&api.GroupRequestQuickOperation{Approve: false, Reason: reason})
+}
+
 // Approve 同意好友请求
 func (e *FriendRequestEvent) Approve(remark string) error {
 	_, err := e.apiSender.SetFriendAddRequest(e.Flag, true, remark)
@@ -377,70 +637,28 @@ func (e *GroupRequestEvent) Reject(reason string) error {
 }
 
 func ParseEvent(data []byte, apiSender *api.Sender) (IEvent, error) {
-	typeInfos := gjson.GetManyBytes(data, "post_type", "message_type", "notice_type", "request_type", "sub_type")
-	var e IEvent
-	var err error
-
-	switch EventType(typeInfos[0].String()) {
-	case EventTypeMessageSent:
-		fallthrough
-	case EventTypeMessage:
-		switch MessageEventType(typeInfos[1].String()) {
-		case MessageEventTypePrivate:
-			e = new(PrivateMessageEvent)
-		case MessageEventTypeGroup:
-			e = new(GroupMessageEvent)
-		default:
-			err = errors.ErrUnknownMessageEvent
-			e = new(MessageEvent)
-		}
+	typeInfos := gjson.GetManyBytes(data, "post_type", "message_type", "notice_type", "request_type", "meta_event_type", "sub_type")
+	key := EventKey{PostType: EventType(typeInfos[0].String()), SubType: typeInfos[5].String()}
+	switch key.PostType {
+	case EventTypeMessage, EventTypeMessageSent:
+		key.DetailType = typeInfos[1].String()
 	case EventTypeNotice:
-		switch NoticeEventType(typeInfos[2].String()) {
-		case NoticeEventTypeGroupUpload:
-			e = new(NoticeEventGroupUpload)
-		case NoticeEventTypeGroupIncrease, NoticeEventTypeGroupDecrease:
-			e = new(NoticeEventGroupOperation)
-		case NoticeEventTypeGroupBan:
-			e = new(NoticeEventGroupBan)
-		case NoticeEventTypeGroupRecall:
-			e = new(NoticeEventGroupRecall)
-		case NoticeEventTypeFriendRecall:
-			e = new(NoticeEventFriendRecall)
-		case NoticeEventTypeGroupAdmin:
-			e = new(GroupNoticeEvent)
-		case NoticeEventTypeNotify:
-			switch NoticeEventSubtype(typeInfos[4].String()) {
-			case NoticeEventSubtypeHonor:
-				e = new(NoticeEventGroupHonor)
-			case NoticeEventSubtypeLuckyKing, NoticeEventSubtypePoke:
-				e = new(NoticeEventGroupNotify)
-			default:
-				err = errors.ErrUnknownNoticeEvent
-				e = new(NoticeEvent)
-			}
-		case NoticeEventTypeFriendAdd:
-			e = new(NoticeEventFriendAdd)
-		default:
-			err = errors.ErrUnknownNoticeEvent
-			e = new(NoticeEvent)
-		}
+		key.DetailType = typeInfos[2].String()
 	case EventTypeRequest:
-		if RequestEventType(typeInfos[3].String()) == RequestEventTypeGroup {
-			e = new(GroupRequestEvent)
-		} else if RequestEventType(typeInfos[3].String()) == RequestEventTypeFriend {
-			e = new(FriendRequestEvent)
-		} else {
-			err = errors.ErrUnknownRequestEvent
-			e = new(RequestEvent)
-		}
+		key.DetailType = typeInfos[3].String()
 	case EventTypeMeta:
-		e = new(MetaEvent)
-	default:
-		err = errors.ErrUnknownEvent
-		e = new(BaseEvent)
+		key.DetailType = typeInfos[4].String()
+	}
+	var e IEvent
+	var err error
+	if newFunc, ok := getEventParser(key); ok {
+		e = newFunc()
+	} else {
+		e, err = newFallbackEvent(key)
 	}
 	e.setError(err)
 	e.setApiSender(apiSender)
+	e.setRaw(data)
 	if err := json.Unmarshal(data, e); err != nil {
 		return e, err
 	}
@@ -0,0 +1,124 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestParseEventKeepsRaw(t *testing.T) {
+	assert := assert.New(t)
+	raw := []byte(`{"time":1,"self_id":2,"post_type":"message","message_type":"private","sub_type":"friend","message_id":3,"user_id":4,"message":[{"type":"text","data":{"text":"hi"}}],"raw_message":"hi","font":0,"sender":{"user_id":4,"nickname":"a"}}`)
+	e, err := ParseEvent(raw, nil)
+	assert.Nil(err)
+	pe := GetAs[PrivateMessageEvent](e)
+	assert.NotNil(pe)
+	assert.Equal(json.RawMessage(raw), pe.quickOpContext(pe))
+}
+
+func TestQuickOpContextFallback(t *testing.T) {
+	assert := assert.New(t)
+	e := newTestPrivateMessageEvent("hi")
+	assert.Equal(e, e.quickOpContext(e))
+}
+
+func TestParseNapCatNoticeEvents(t *testing.T) {
+	assert := assert.New(t)
+	base := `"time":1,"self_id":2,"post_type":"notice"`
+	e, err := ParseEvent([]byte(`{`+base+`,"notice_type":"group_card","group_id":3,"user_id":4,"card_new":"new","card_old":"old"}`), nil)
+	assert.Nil(err)
+	card := GetAs[NoticeEventGroupCard](e)
+	assert.NotNil(card)
+	assert.Equal("new", card.CardNew)
+	assert.Equal("old", card.CardOld)
+	assert.EqualValues(3, card.GetGroupId())
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"essence","sub_type":"add","group_id":3,"message_id":5,"sender_id":4,"operator_id":6}`), nil)
+	assert.Nil(err)
+	essence := GetAs[NoticeEventEssence](e)
+	assert.NotNil(essence)
+	assert.Equal(NoticeEventSubtypeAdd, essence.SubType)
+	assert.EqualValues(6, essence.OperatorId)
+	assert.EqualValues(4, essence.SenderId)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"group_msg_emoji_like","group_id":3,"user_id":4,"message_id":5,"likes":[{"emoji_id":"76","count":2}]}`), nil)
+	assert.Nil(err)
+	like := GetAs[NoticeEventGroupMsgEmojiLike](e)
+	assert.NotNil(like)
+	assert.Equal([]EmojiLike{{EmojiId: "76", Count: 2}}, like.Likes)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"notify","sub_type":"input_status","user_id":4,"group_id":0,"status_text":"typing","event_type":1}`), nil)
+	assert.Nil(err)
+	input := GetAs[NoticeEventInputStatus](e)
+	assert.NotNil(input)
+	assert.Equal("typing", input.StatusText)
+	assert.Equal(1, input.InputEventType)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"notify","sub_type":"profile_like","operator_id":4,"operator_nick":"a","times":10}`), nil)
+	assert.Nil(err)
+	profileLike := GetAs[NoticeEventProfileLike](e)
+	assert.NotNil(profileLike)
+	assert.Equal(10, profileLike.Times)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"notify","sub_type":"title","group_id":3,"user_id":4,"title":"t"}`), nil)
+	assert.Nil(err)
+	assert.Equal("t", GetAs[NoticeEventGroupTitle](e).Title)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"notify","sub_type":"group_name","group_id":3,"user_id":4,"name_new":"n"}`), nil)
+	assert.Nil(err)
+	assert.Equal("n", GetAs[NoticeEventGroupName](e).NameNew)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"offline_file","user_id":4,"file":{"name":"a.txt","size":12,"url":"u"}}`), nil)
+	assert.Nil(err)
+	assert.Equal("a.txt", GetAs[NoticeEventOfflineFile](e).File.Name)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"client_status","client":{"app_id":1,"device_name":"pc","device_kind":"windows"},"online":true}`), nil)
+	assert.Nil(err)
+	status := GetAs[NoticeEventClientStatus](e)
+	assert.True(status.Online)
+	assert.Equal("pc", status.Client.DeviceName)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"bot_offline","user_id":2,"tag":"tag","message":"msg"}`), nil)
+	assert.Nil(err)
+	assert.Equal("msg", GetAs[NoticeEventBotOffline](e).Message)
+
+	e, err = ParseEvent([]byte(`{`+base+`,"notice_type":"notify","sub_type":"poke","group_id":3,"user_id":4,"target_id":2}`), nil)
+	assert.Nil(err)
+	assert.EqualValues(2, GetAs[NoticeEventGroupNotify](e).TargetId)
+}
+
+type testCustomNoticeEvent struct {
+	NoticeEvent
+	Custom string `json:"custom"`
+}
+
+func TestRegisterNoticeEvent(t *testing.T) {
+	assert := assert.New(t)
+	raw := []byte(`{"time":1,"self_id":2,"post_type":"notice","notice_type":"test_custom","sub_type":"x","custom":"value"}`)
+	e, err := ParseEvent(raw, nil)
+	assert.ErrorIs(err, errors.ErrUnknownNoticeEvent)
+	assert.IsType(&NoticeEvent{}, e)
+
+	assert.Nil(RegisterNoticeEvent("test_custom", "", func() IEvent { return new(testCustomNoticeEvent) }))
+	assert.ErrorIs(RegisterNoticeEvent("test_custom", "", func() IEvent { return new(testCustomNoticeEvent) }), errors.ErrEventAlreadyRegistered)
+	e, err = ParseEvent(raw, nil)
+	assert.Nil(err)
+	assert.Equal("value", GetAs[testCustomNoticeEvent](e).Custom)
+}
+
+func TestRegisterHandlerNoticeType(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	var received []NoticeEventType
+	d.RegisterHandlerNoticeType(NoticeEventTypeGroupCard, func(event IEvent) {
+		received = append(received, event.(INoticeEvent).GetNoticeType())
+	})
+	card, _ := ParseEvent([]byte(`{"post_type":"notice","notice_type":"group_card","group_id":3}`), nil)
+	ban, _ := ParseEvent([]byte(`{"post_type":"notice","notice_type":"group_ban","group_id":3}`), nil)
+	d.Dispatch(card)
+	d.Dispatch(ban)
+	assert.Equal([]NoticeEventType{NoticeEventTypeGroupCard}, received)
+}
@@ -0,0 +1,116 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nekoite/go-napcat/api"
+	"github.com/nekoite/go-napcat/qq"
+)
+
+// 由本库生成的元事件类型，不会由 OneBot 实现上报
+const (
+	// MetaEventTypeHeartbeatTimeout 连续多个心跳周期没有收到心跳
+	MetaEventTypeHeartbeatTimeout MetaEventType = "heartbeat_timeout"
+	// MetaEventTypeStatusUnhealthy 心跳中的状态变为不健康（online 或 good 为 false）
+	MetaEventTypeStatusUnhealthy MetaEventType = "status_unhealthy"
+)
+
+// Health 根据心跳得到的连接健康状况
+type Health struct {
+	// LastHeartbeat 最近一次收到心跳的时间，没有收到过心跳时为零值
+	LastHeartbeat time.Time
+	// Interval 心跳间隔
+	Interval time.Duration
+	// Status 最近一次心跳中的状态，没有收到过心跳时为 nil
+	Status *api.ServerStatus
+	// HeartbeatTimeout 是否已经超时没有收到心跳
+	HeartbeatTimeout bool
+	// Healthy 没有超时，且服务端状态正常（或还没有收到过心跳）
+	Healthy bool
+}
+
+// HeartbeatMonitor 根据心跳元事件监控连接状况。
+type HeartbeatMonitor struct {
+	mu          sync.Mutex
+	missedBeats int
+	last        time.Time
+	interval    time.Duration
+	status      *api.ServerStatus
+	timedOut    bool
+	unhealthy   bool
+}
+
+// NewHeartbeatMonitor 创建心跳监控。连续 missedBeats 个心跳间隔没有收到心跳时视为超时。
+func NewHeartbeatMonitor(missedBeats int) *HeartbeatMonitor {
+	if missedBeats <= 0 {
+		missedBeats = 3
+	}
+	return &HeartbeatMonitor{missedBeats: missedBeats}
+}
+
+// Observe 记录一次心跳事件。返回服务端状态是否刚刚从健康变为不健康。
+func (m *HeartbeatMonitor) Observe(e *MetaEvent, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last = now
+	m.timedOut = false
+	if e.Interval > 0 {
+		m.interval = time.Duration(e.Interval) * time.Millisecond
+	}
+	m.status = e.Status
+	unhealthy := e.Status != nil && (!e.Status.Online || !e.Status.Good)
+	becameUnhealthy := unhealthy && !m.unhealthy
+	m.unhealthy = unhealthy
+	return becameUnhealthy
+}
+
+// Check 检查心跳是否超时，只在刚刚超时时返回 true。还没有收到过心跳时不会超时。
+func (m *HeartbeatMonitor) Check(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.timedOut || m.last.IsZero() || m.interval <= 0 {
+		return false
+	}
+	if now.Sub(m.last) > time.Duration(m.missedBeats)*m.interval {
+		m.timedOut = true
+		return true
+	}
+	return false
+}
+
+// Reset 清除超时状态并从 now 开始重新计时，用于重新连接后。
+func (m *HeartbeatMonitor) Reset(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.last.IsZero() {
+		m.last = now
+	}
+	m.timedOut = false
+}
+
+func (m *HeartbeatMonitor) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Health{
+		LastHeartbeat:    m.last,
+		Interval:         m.interval,
+		Status:           m.status,
+		HeartbeatTimeout: m.timedOut,
+		Healthy:          !m.timedOut && !m.unhealthy,
+	}
+}
+
+// NewSyntheticMetaEvent 创建由本库生成的元事件，例如 [MetaEventTypeHeartbeatTimeout]。
+func NewSyntheticMetaEvent(selfId qq.UserId, metaEventType MetaEventType, health Health, apiSender *api.Sender) *MetaEvent {
+	e := &MetaEvent{
+		MetaEventType: metaEventType,
+		Status:        health.Status,
+		Interval:      health.Interval.Milliseconds(),
+	}
+	e.Time = time.Now().Unix()
+	e.SelfId = selfId
+	e.EventType = EventTypeMeta
+	e.setApiSender(apiSender)
+	return e
+}
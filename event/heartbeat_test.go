@@ -0,0 +1,68 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nekoite/go-napcat/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHeartbeat(online, good bool) *MetaEvent {
+	e := &MetaEvent{
+		MetaEventType: MetaEventTypeHeartbeat,
+		Interval:      1000,
+		Status:        &api.ServerStatus{Online: online, Good: good},
+	}
+	e.EventType = EventTypeMeta
+	return e
+}
+
+func TestHeartbeatMonitorTimeout(t *testing.T) {
+	assert := assert.New(t)
+	m := NewHeartbeatMonitor(3)
+	now := time.Now()
+	assert.False(m.Check(now.Add(time.Hour)))
+	assert.True(m.Health().Healthy)
+
+	assert.False(m.Observe(newTestHeartbeat(true, true), now))
+	assert.False(m.Check(now.Add(3 * time.Second)))
+	assert.True(m.Check(now.Add(3*time.Second + time.Millisecond)))
+	assert.False(m.Check(now.Add(4 * time.Second)))
+	h := m.Health()
+	assert.True(h.HeartbeatTimeout)
+	assert.False(h.Healthy)
+	assert.Equal(time.Second, h.Interval)
+	assert.Equal(now, h.LastHeartbeat)
+
+	m.Reset(now.Add(4 * time.Second))
+	assert.True(m.Health().Healthy)
+	assert.False(m.Check(now.Add(7 * time.Second)))
+	assert.True(m.Check(now.Add(8 * time.Second)))
+
+	m.Observe(newTestHeartbeat(true, true), now.Add(9*time.Second))
+	assert.True(m.Health().Healthy)
+}
+
+func TestHeartbeatMonitorUnhealthyStatus(t *testing.T) {
+	assert := assert.New(t)
+	m := NewHeartbeatMonitor(3)
+	now := time.Now()
+	assert.False(m.Observe(newTestHeartbeat(true, true), now))
+	assert.True(m.Observe(newTestHeartbeat(true, false), now))
+	assert.False(m.Observe(newTestHeartbeat(false, false), now))
+	assert.False(m.Health().Healthy)
+	assert.False(m.Observe(newTestHeartbeat(true, true), now))
+	assert.True(m.Health().Healthy)
+	assert.True(m.Observe(newTestHeartbeat(false, true), now))
+}
+
+func TestNewSyntheticMetaEvent(t *testing.T) {
+	assert := assert.New(t)
+	status := &api.ServerStatus{Online: false}
+	e := NewSyntheticMetaEvent(1, MetaEventTypeHeartbeatTimeout, Health{Interval: time.Second, Status: status}, nil)
+	assert.Equal(EventTypeMeta, e.GetEventType())
+	assert.EqualValues(1, e.GetSelfId())
+	assert.EqualValues(1000, e.Interval)
+	assert.Equal(status, e.Status)
+}
@@ -0,0 +1,203 @@
+package event
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/nekoite/go-napcat/i18n"
+	"github.com/nekoite/go-napcat/message"
+	"go.uber.org/zap"
+)
+
+type HelpCommandArgs struct {
+	Command []string `arg:"" optional:"" help:"要查看用法的指令及子指令" i18n:"help.args.command"`
+	Page    int      `short:"p" default:"1" help:"页码" i18n:"help.args.page"`
+}
+
+// HelpCommand 内置的帮助指令。不带参数时列出调用者有权限执行的所有指令，`help <指令> [子指令...]` 显示 kong 生成的用法。
+// 内容超过一页时分页显示，或者以合并转发消息发送。
+type HelpCommand struct {
+	center *CommandCenter
+
+	Name    string
+	Aliases []string
+	// PageSize 每页的行数，小于等于 0 时不分页
+	PageSize int
+	// Forward 为 true 时，内容超过一页时以合并转发消息发送，每页为一个节点
+	Forward bool
+	// ForwardNickname 合并转发消息中节点的昵称，可以是消息目录中的键
+	ForwardNickname string
+}
+
+// NewHelpCommand 创建帮助指令，默认名称为 help，别名为 帮助。
+func NewHelpCommand(center *CommandCenter) *HelpCommand {
+	return &HelpCommand{
+		center:          center,
+		Name:            "help",
+		Aliases:         []string{"帮助"},
+		PageSize:        10,
+		ForwardNickname: "help.forward_nickname",
+	}
+}
+
+func (h *HelpCommand) GetName() (string, CmdNameMode) {
+	return h.Name, CmdNameModeNormal
+}
+
+func (h *HelpCommand) GetAliases() []string {
+	return h.Aliases
+}
+
+// GetDescription 返回消息目录中描述的键，显示时翻译为当前聊天的语言。
+func (h *HelpCommand) GetDescription() string {
+	return "help.description"
+}
+
+func (h *HelpCommand) GetNew() any {
+	return &HelpCommandArgs{}
+}
+
+func (h *HelpCommand) GetOptions() []kong.Option {
+	return nil
+}
+
+func (h *HelpCommand) SplitBySpaceOnly() bool {
+	return true
+}
+
+func (h *HelpCommand) StopPropagation() bool {
+	return true
+}
+
+func (h *HelpCommand) OnCommand(parseResult *ParseResult) {
+	var reply *message.Chain
+	quote := true
+	if parseResult.Error != nil {
+		reply = message.NewText(parseResult.Error.Error()).Segment().AsChain()
+	} else {
+		reply, quote = h.render(parseResult.Event, parseResult.ParsedArgs.(*HelpCommandArgs))
+	}
+	if _, err := parseResult.Event.Reply(reply, quote); err != nil {
+		h.center.logger.Error("failed to send help", zap.Error(err))
+	}
+}
+
+// render 生成帮助内容。以合并转发消息发送时不引用原消息。
+func (h *HelpCommand) render(e IMessageEvent, args *HelpCommandArgs) (*message.Chain, bool) {
+	locale := h.center.Locale(e)
+	if len(args.Command) == 0 {
+		return h.paginate(e, h.center.catalog.Translate(locale, "help.header", nil), h.listCommands(e), args.Page)
+	}
+	cmd := h.findCommand(e, args.Command[0])
+	if cmd == nil {
+		return message.NewText(h.center.catalog.Translate(locale, "help.not_found", i18n.Args{"command": args.Command[0]})).Segment().AsChain(), true
+	}
+	usage, err := h.center.renderUsage(cmd, args.Command[1:], locale)
+	if err != nil {
+		return message.NewText(err.Error()).Segment().AsChain(), true
+	}
+	return h.paginate(e, "", strings.Split(strings.TrimRight(usage, "\n"), "\n"), args.Page)
+}
+
+// listCommands 返回调用者有权限执行的指令列表，每个指令一行。指令的描述可以是消息目录中的键。
+func (h *HelpCommand) listCommands(e IMessageEvent) []string {
+	c := h.center
+	locale := c.Locale(e)
+	seen := make(map[string]struct{})
+	lines := make([]string, 0, len(c.Commands)+len(c.PrefixCommands))
+	prefix := displayPrefix(c.prefixesFor(e))
+	add := func(cmd ICommand, prefix string) {
+		names := commandNames(cmd)
+		if _, ok := seen[names[0]]; ok || !c.isEnabled(e, cmd) || !c.hasPermission(e, cmd) {
+			return
+		}
+		seen[names[0]] = struct{}{}
+		sb := strings.Builder{}
+		sb.WriteString(prefix + names[0])
+		if len(names) > 1 {
+			aliases := strings.Join(names[1:], c.catalog.Translate(locale, "list.separator", nil))
+			sb.WriteString(c.catalog.Translate(locale, "help.aliases", i18n.Args{"aliases": aliases}))
+		}
+		if descCmd, ok := cmd.(ICommandWithDescription); ok && descCmd.GetDescription() != "" {
+			desc := c.catalog.Translate(locale, descCmd.GetDescription(), nil)
+			sb.WriteString(c.catalog.Translate(locale, "help.entry_description", i18n.Args{"description": desc}))
+		}
+		lines = append(lines, sb.String())
+	}
+	for _, cmd := range c.PrefixCommands {
+		add(cmd, prefix)
+	}
+	for _, cmd := range c.Commands {
+		add(cmd, prefix)
+	}
+	// 正则指令与关键词指令不需要全局激活前缀
+	for _, cmd := range slices.Concat(c.RegexCommands, c.KeywordCommands) {
+		add(cmd, "")
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// findCommand 按名称或别名查找调用者有权限执行的指令。
+func (h *HelpCommand) findCommand(e IMessageEvent, name string) ICommand {
+	c := h.center
+	for _, prefix := range c.prefixesFor(e) {
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	cmd, ok := c.Commands[name]
+	if !ok {
+		for _, other := range slices.Concat(c.PrefixCommands, c.RegexCommands, c.KeywordCommands) {
+			if slices.Contains(commandNames(other), name) {
+				cmd = other
+				break
+			}
+		}
+	}
+	if cmd == nil || !c.isEnabled(e, cmd) || !c.hasPermission(e, cmd) {
+		return nil
+	}
+	return cmd
+}
+
+// paginate 将内容分页。内容超过一页时按设置发送指定的页，或者以合并转发消息发送所有页。
+func (h *HelpCommand) paginate(e IMessageEvent, header string, lines []string, page int) (*message.Chain, bool) {
+	join := func(lines []string) string {
+		if header == "" {
+			return strings.Join(lines, "\n")
+		}
+		return header + "\n" + strings.Join(lines, "\n")
+	}
+	if h.PageSize <= 0 || len(lines) <= h.PageSize {
+		return message.NewText(join(lines)).Segment().AsChain(), true
+	}
+	pages := slices.Collect(slices.Chunk(lines, h.PageSize))
+	if h.Forward {
+		chain := message.NewChain()
+		nickname := h.center.Translate(e, h.ForwardNickname, nil)
+		for _, p := range pages {
+			chain.AddSegment(message.NewCustomNode(e.GetSelfId(), nickname, join(p)).Segment())
+		}
+		return chain, false
+	}
+	page = min(max(page, 1), len(pages))
+	footer := h.center.Translate(e, "help.page", i18n.Args{
+		"page":   page,
+		"total":  len(pages),
+		"prefix": displayPrefix(h.center.prefixesFor(e)),
+		"name":   h.Name,
+	})
+	text := join(pages[page-1]) + "\n" + footer
+	return message.NewText(text).Segment().AsChain(), true
+}
+
+// displayPrefix 返回显示在指令前面的前缀，即第一个全局激活前缀。
+func displayPrefix(prefixes []string) string {
+	if len(prefixes) == 0 {
+		return ""
+	}
+	return prefixes[0]
+}
@@ -0,0 +1,114 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testDescCommand struct {
+	testAliasCommand
+	desc  string
+	perms []Permission
+}
+
+func (c *testDescCommand) GetDescription() string {
+	return c.desc
+}
+
+func (c *testDescCommand) GetPermissions() []Permission {
+	return c.perms
+}
+
+type testMusicArgs struct {
+	Platform string `short:"p" help:"音乐平台"`
+	SongName string `arg:"" help:"歌曲名"`
+}
+
+func newTestHelpCenter() (*CommandCenter, *HelpCommand) {
+	c := NewCommandCenter(zap.NewNop())
+	c.RegisterCommand(&testDescCommand{
+		testAliasCommand: testAliasCommand{
+			testCommand: testCommand{name: "music", mode: CmdNameModeNormal, getNew: func() any { return &testMusicArgs{} }},
+			aliases:     []string{"点歌"},
+		},
+		desc: "点歌",
+	})
+	c.RegisterCommand(&testDescCommand{
+		testAliasCommand: testAliasCommand{
+			testCommand: testCommand{name: "ban", mode: CmdNameModeNormal, getNew: func() any { return &struct{}{} }},
+		},
+		desc:  "禁言",
+		perms: []Permission{PermissionGroupAdmin},
+	})
+	c.RegisterCommand(&CommandGroup{Name: "admin", Description: "管理", New: func() any { return &testAdminArgs{} }})
+	help := NewHelpCommand(c)
+	c.RegisterCommand(help)
+	return c, help
+}
+
+func renderText(chain *message.Chain) string {
+	return chain.FirstText().Text
+}
+
+func TestHelpListFiltersByPermission(t *testing.T) {
+	assert := assert.New(t)
+	_, help := newTestHelpCenter()
+	member := newTestGroupMessageEventWithRole("", 10, 1, qq.GroupRoleMember)
+	chain, quote := help.render(member, &HelpCommandArgs{Page: 1})
+	assert.True(quote)
+	assert.Equal("可用指令：\nadmin：管理\nhelp（帮助）：显示指令列表或指令的用法\nmusic（点歌）：点歌", renderText(chain))
+
+	admin := newTestGroupMessageEventWithRole("", 10, 2, qq.GroupRoleAdmin)
+	chain, _ = help.render(admin, &HelpCommandArgs{Page: 1})
+	assert.Contains(renderText(chain), "ban：禁言")
+}
+
+func TestHelpUsage(t *testing.T) {
+	assert := assert.New(t)
+	_, help := newTestHelpCenter()
+	e := newTestGroupMessageEventWithRole("", 10, 1, qq.GroupRoleMember)
+	chain, _ := help.render(e, &HelpCommandArgs{Command: []string{"点歌"}, Page: 1})
+	text := renderText(chain)
+	assert.Contains(text, "用法：music <song-name>")
+	assert.Contains(text, "音乐平台")
+
+	chain, _ = help.render(e, &HelpCommandArgs{Command: []string{"admin", "kick"}, Page: 1})
+	assert.Contains(renderText(chain), "用法：admin kick (踢) <user>")
+
+	chain, _ = help.render(e, &HelpCommandArgs{Command: []string{"ban"}, Page: 1})
+	assert.Equal("未找到指令 ban", renderText(chain))
+	chain, _ = help.render(e, &HelpCommandArgs{Command: []string{"nope"}, Page: 1})
+	assert.Equal("未找到指令 nope", renderText(chain))
+}
+
+func TestHelpPagination(t *testing.T) {
+	assert := assert.New(t)
+	c, help := newTestHelpCenter()
+	for i := 0; i < 20; i++ {
+		c.RegisterCommand(&testCommand{name: fmt.Sprintf("cmd%02d", i), mode: CmdNameModeNormal})
+	}
+	e := newTestGroupMessageEventWithRole("", 10, 1, qq.GroupRoleMember)
+	e.SelfId = 42
+
+	chain, _ := help.render(e, &HelpCommandArgs{Page: 3})
+	text := renderText(chain)
+	assert.True(strings.HasPrefix(text, "可用指令：\ncmd19\nhelp（帮助）"))
+	assert.True(strings.HasSuffix(text, "第 3/3 页，发送 help -p <页码> 查看其它页"))
+
+	chain, _ = help.render(e, &HelpCommandArgs{Page: 100})
+	assert.Contains(renderText(chain), "第 3/3 页")
+
+	help.Forward = true
+	chain, quote := help.render(e, &HelpCommandArgs{Page: 1})
+	assert.False(quote)
+	assert.Equal(3, chain.Len())
+	node := chain.At(0).Data.(*message.CustomNodeData)
+	assert.Equal(qq.UserId(42), node.UserId)
+	assert.Equal("帮助", node.Nickname)
+}
@@ -0,0 +1,108 @@
+package event
+
+import (
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/nekoite/go-napcat/i18n"
+)
+
+// LocaleSettingKey 保存群或私聊语言的设置名称，例如 `scope set locale en-US`
+const LocaleSettingKey = "locale"
+
+// i18nTag 参数结构体与子指令中指定帮助文本翻译键的标签，例如 `help:"歌曲名" i18n:"music.song"`
+const i18nTag = "i18n"
+
+// Catalog 返回库生成的消息使用的消息目录。
+func (c *CommandCenter) Catalog() *i18n.Catalog {
+	return c.catalog
+}
+
+// SetCatalog 设置库生成的消息使用的消息目录。
+func (c *CommandCenter) SetCatalog(catalog *i18n.Catalog) {
+	c.catalog = catalog
+}
+
+// SetDefaultLocale 设置没有单独设置语言的群和私聊使用的语言，默认为 [i18n.DefaultLocale]。
+func (c *CommandCenter) SetDefaultLocale(locale string) {
+	c.defaultLocale = locale
+}
+
+// Locale 返回事件所在的群或私聊使用的语言。
+func (c *CommandCenter) Locale(event IEvent) string {
+	if scope, ok := ScopeOf(event); ok {
+		if locale, ok := c.scopes.Get(scope, LocaleSettingKey); ok && locale != "" {
+			return locale
+		}
+	}
+	return c.defaultLocale
+}
+
+// Translate 使用事件所在聊天的语言翻译消息。找不到 key 时 key 本身将作为消息模板。
+func (c *CommandCenter) Translate(event IEvent, key string, args i18n.Args) string {
+	return c.catalog.Translate(c.Locale(event), key, args)
+}
+
+// translateHelp 将 kong 模型中带 i18n 标签的帮助文本临时替换为 locale 的翻译，返回的函数用于恢复。
+// 调用者需要独占解析器。
+func (c *CommandCenter) translateHelp(k *kong.Kong, locale string) func() {
+	type saved struct {
+		help *string
+		orig string
+	}
+	var restore []saved
+	replace := func(help *string, key string) {
+		if key == "" {
+			return
+		}
+		if text, ok := c.catalog.Lookup(locale, key); ok {
+			restore = append(restore, saved{help, *help})
+			*help = text
+		}
+	}
+	var helpFlag *kong.Value
+	if k.Model.HelpFlag != nil {
+		helpFlag = k.Model.HelpFlag.Value
+		replace(&helpFlag.Help, "kong.help_flag")
+	}
+	_ = kong.Visit(k.Model, func(node kong.Visitable, next kong.Next) error {
+		switch n := node.(type) {
+		case *kong.Node:
+			if n.Tag != nil {
+				replace(&n.Help, n.Tag.Get(i18nTag))
+			}
+		case *kong.Value:
+			if n != helpFlag && n.Tag != nil {
+				replace(&n.Help, n.Tag.Get(i18nTag))
+			}
+		}
+		return next(nil)
+	})
+	return func() {
+		for _, s := range restore {
+			*s.help = s.orig
+		}
+	}
+}
+
+// translateUsage 翻译 kong 生成的用法中的标题。
+func (c *CommandCenter) translateUsage(usage string, locale string) string {
+	headings := map[string]string{
+		"Arguments:": "kong.arguments",
+		"Flags:":     "kong.flags",
+		"Commands:":  "kong.commands",
+	}
+	lines := strings.Split(usage, "\n")
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "Usage: "); ok {
+			if tmpl, ok := c.catalog.Lookup(locale, "kong.usage"); ok {
+				lines[i] = i18n.Format(tmpl, i18n.Args{"usage": rest})
+			}
+		} else if key, ok := headings[line]; ok {
+			if text, ok := c.catalog.Lookup(locale, key); ok {
+				lines[i] = text
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
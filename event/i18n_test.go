@@ -0,0 +1,121 @@
+package event
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testI18nArgs struct {
+	Platform string `short:"p" help:"音乐平台" i18n:"test.platform"`
+	SongName string `arg:"" help:"歌曲名" i18n:"test.song"`
+}
+
+func newTestI18nCenter() *CommandCenter {
+	c := NewCommandCenter(zap.NewNop())
+	c.Catalog().Set("en-US", "test.platform", "Music platform")
+	c.Catalog().Set("en-US", "test.song", "Song name")
+	c.Catalog().Set("en-US", "test.description", "Play a song")
+	c.Catalog().Set("zh-CN", "test.description", "点歌")
+	return c
+}
+
+func TestLocalePerScope(t *testing.T) {
+	assert := assert.New(t)
+	c := newTestI18nCenter()
+	e := newTestGroupMessageEvent("", 10, 1)
+	assert.Equal("zh-CN", c.Locale(e))
+	assert.NoError(c.Scopes().Set(GroupScope(10), LocaleSettingKey, "en-US"))
+	assert.Equal("en-US", c.Locale(e))
+	assert.Equal("zh-CN", c.Locale(newTestGroupMessageEvent("", 11, 1)))
+	c.SetDefaultLocale("en-US")
+	assert.Equal("en-US", c.Locale(newTestPrivateMessageEvent("")))
+	assert.Equal("Please try again in 3 seconds", c.Translate(e, "command.cooldown", map[string]any{"seconds": 3}))
+}
+
+func TestHelpTranslated(t *testing.T) {
+	assert := assert.New(t)
+	c := newTestI18nCenter()
+	c.RegisterCommand(&testDescCommand{
+		testAliasCommand: testAliasCommand{
+			testCommand: testCommand{name: "music", mode: CmdNameModeNormal, getNew: func() any { return &testI18nArgs{} }},
+		},
+		desc: "test.description",
+	})
+	help := NewHelpCommand(c)
+	c.RegisterCommand(help)
+	c.Scopes().Set(GroupScope(10), LocaleSettingKey, "en-US")
+	en := newTestGroupMessageEventWithRole("", 10, 1, qq.GroupRoleMember)
+	zh := newTestGroupMessageEventWithRole("", 11, 1, qq.GroupRoleMember)
+
+	chain, _ := help.render(en, &HelpCommandArgs{Page: 1})
+	assert.Equal("Commands:\nhelp (帮助): List commands or show the usage of a command\nmusic: Play a song", renderText(chain))
+	chain, _ = help.render(zh, &HelpCommandArgs{Page: 1})
+	assert.Equal("可用指令：\nhelp（帮助）：显示指令列表或指令的用法\nmusic：点歌", renderText(chain))
+
+	chain, _ = help.render(en, &HelpCommandArgs{Command: []string{"music"}, Page: 1})
+	text := renderText(chain)
+	assert.True(strings.HasPrefix(text, "Usage: music <song-name>"), text)
+	assert.Contains(text, "Arguments:")
+	assert.Contains(text, "Song name")
+	assert.Contains(text, "Music platform")
+
+	// 翻译只在渲染期间生效，之后恢复原来的帮助文本
+	chain, _ = help.render(zh, &HelpCommandArgs{Command: []string{"music"}, Page: 1})
+	text = renderText(chain)
+	assert.True(strings.HasPrefix(text, "用法：music <song-name>"), text)
+	assert.Contains(text, "歌曲名")
+	assert.Contains(text, "音乐平台")
+	assert.NotContains(text, "Song name")
+
+	chain, _ = help.render(en, &HelpCommandArgs{Command: []string{"nope"}, Page: 1})
+	assert.Equal("Command nope not found", renderText(chain))
+}
+
+func TestParseErrorTranslated(t *testing.T) {
+	assert := assert.New(t)
+	c := newTestI18nCenter()
+	policy := DefaultReplyPolicy()
+	policy.ParseError = true
+	c.SetReplyPolicy(policy)
+	c.RegisterCommand(&testCommand{name: "music", mode: CmdNameModeNormal, getNew: func() any { return &testI18nArgs{} }})
+	e := newTestReplyEvent("music")
+	c.Scopes().Set(PrivateScope(e.UserId), LocaleSettingKey, "en-US")
+	c.onMessageRecv(e)
+	if assert.Len(e.replies, 1) {
+		text := e.replies[0].Messages[0].Data.(*message.TextData).Text
+		assert.True(strings.HasPrefix(text, "Invalid arguments: "), text)
+		assert.Contains(text, "Usage: music <song-name>")
+		assert.Contains(text, "Song name")
+	}
+
+	e = newTestReplyEvent("music --help")
+	c.onMessageRecv(e)
+	if assert.Len(e.replies, 1) {
+		text := e.replies[0].Messages[0].Data.(*message.TextData).Text
+		assert.True(strings.HasPrefix(text, "Usage: music <song-name>"), text)
+		assert.Contains(text, "Show context-sensitive help.")
+	}
+}
+
+func TestPermissionDeniedReplyTranslated(t *testing.T) {
+	assert := assert.New(t)
+	c := newTestI18nCenter()
+	c.SetPermissionDeniedReply("command.permission_denied")
+	c.RegisterCommand(&testDescCommand{
+		testAliasCommand: testAliasCommand{testCommand: testCommand{name: "ban", mode: CmdNameModeNormal}},
+		perms:            []Permission{PermissionSuperuser},
+	})
+	e := newTestReplyEvent("ban")
+	c.onMessageRecv(e)
+	c.Scopes().Set(PrivateScope(e.UserId), LocaleSettingKey, "en-US")
+	c.onMessageRecv(e)
+	if assert.Len(e.replies, 2) {
+		assert.Equal("你没有权限执行这个指令", e.replies[0].Messages[0].Data.(*message.TextData).Text)
+		assert.Equal("You do not have permission to run this command", e.replies[1].Messages[0].Data.(*message.TextData).Text)
+	}
+}
@@ -0,0 +1,76 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nekoite/go-napcat/qq"
+	"go.uber.org/zap"
+)
+
+// MentionMode 指令是否需要 @ 机器人才能触发
+type MentionMode string
+
+const (
+	// MentionModeNone 不处理 @ 机器人（默认）
+	MentionModeNone MentionMode = ""
+	// MentionModeOptional 消息开头 @ 机器人时去掉这个 @ 后再匹配指令，但不要求 @
+	MentionModeOptional MentionMode = "optional"
+	// MentionModeRequired 所有聊天中都需要 @ 机器人（或回复机器人的消息）才能触发指令
+	MentionModeRequired MentionMode = "required"
+	// MentionModeRequiredInGroup 群聊中需要 @ 机器人（或回复机器人的消息），私聊中不需要
+	MentionModeRequiredInGroup MentionMode = "required_in_group"
+)
+
+// SetMentionMode 设置指令是否需要 @ 机器人才能触发。
+func (c *CommandCenter) SetMentionMode(mode MentionMode) {
+	c.mentionMode = mode
+}
+
+// stripMention 去掉消息开头 @ 机器人的消息片段。返回剩余的消息，以及消息是否 @ 了机器人。
+func (c *CommandCenter) stripMention(event IMessageEvent, raw string) (string, bool) {
+	if c.mentionMode == MentionModeNone {
+		return raw, false
+	}
+	selfId := event.GetSelfId()
+	if selfId != 0 {
+		if rest, ok := strings.CutPrefix(raw, fmt.Sprintf("[CQ:at,qq=%d]", selfId)); ok {
+			return strings.TrimLeft(rest, " "), true
+		}
+	}
+	return raw, false
+}
+
+// checkMention 判断已经匹配到指令的消息是否满足 @ 机器人的要求。
+// 没有 @ 机器人但回复了消息时才查询被回复的消息是否由机器人发送，所以需要在匹配指令之后调用，避免普通聊天中的回复也触发查询。
+func (c *CommandCenter) checkMention(event IMessageEvent, mentioned bool, replyTo qq.MessageId) bool {
+	if mentioned || !c.mentionRequired(event) {
+		return true
+	}
+	return replyTo != 0 && c.isBotMessage(event, replyTo)
+}
+
+// mentionRequired 判断在事件所在的聊天中是否需要 @ 机器人。
+func (c *CommandCenter) mentionRequired(event IMessageEvent) bool {
+	switch c.mentionMode {
+	case MentionModeRequired:
+		return true
+	case MentionModeRequiredInGroup:
+		return event.GetMessageEventType() == MessageEventTypeGroup
+	default:
+		return false
+	}
+}
+
+// isBotMessage 查询消息是否由机器人自己发送。
+func (c *CommandCenter) isBotMessage(event IMessageEvent, messageId qq.MessageId) bool {
+	if event.getApiSender() == nil {
+		return false
+	}
+	resp, err := event.getApiSender().GetMsg(messageId)
+	if err != nil {
+		c.logger.Debug("failed to get replied message", zap.Error(err))
+		return false
+	}
+	return resp.Data.Sender != nil && resp.Data.Sender.GetUserId() == event.GetSelfId()
+}
@@ -0,0 +1,85 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/nekoite/go-napcat/api"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func dispatchMention(mode MentionMode, groupMsg bool, raw string) (bool, string) {
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetCommandMentionMode(mode)
+	called := false
+	var name string
+	d.RegisterCommand(&testCommand{
+		name:   "ping",
+		mode:   CmdNameModeNormal,
+		getNew: func() any { return &struct{}{} },
+		onCommand: func(parseResult *ParseResult) {
+			called = true
+			name = parseResult.Name
+		},
+	})
+	var e IMessageEvent
+	if groupMsg {
+		ge := newTestGroupMessageEvent(raw, 10, 1)
+		ge.SelfId = 99
+		e = ge
+	} else {
+		pe := newTestPrivateMessageEvent(raw)
+		pe.UserId = 1
+		pe.SelfId = 99
+		e = pe
+	}
+	d.Dispatch(e)
+	return called, name
+}
+
+func TestMentionModes(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		mode     MentionMode
+		group    bool
+		raw      string
+		expected bool
+	}{
+		{MentionModeNone, true, "ping", true},
+		{MentionModeNone, true, "[CQ:at,qq=99] ping", false},
+		{MentionModeOptional, true, "ping", true},
+		{MentionModeOptional, true, "[CQ:at,qq=99] ping", true},
+		{MentionModeOptional, true, "[CQ:at,qq=98] ping", false},
+		{MentionModeRequired, true, "ping", false},
+		{MentionModeRequired, false, "ping", false},
+		{MentionModeRequired, false, "[CQ:at,qq=99]ping", true},
+		{MentionModeRequired, true, "[CQ:reply,id=5][CQ:at,qq=99] ping", true},
+		{MentionModeRequired, true, "[CQ:reply,id=5]ping", false},
+		{MentionModeRequiredInGroup, true, "ping", false},
+		{MentionModeRequiredInGroup, true, "[CQ:at,qq=99] ping", true},
+		{MentionModeRequiredInGroup, false, "ping", true},
+		{MentionModeRequiredInGroup, false, "[CQ:at,qq=99] ping", true},
+	}
+	for _, c := range cases {
+		called, name := dispatchMention(c.mode, c.group, c.raw)
+		assert.Equal(c.expected, called, "mode=%q group=%v raw=%q", c.mode, c.group, c.raw)
+		if called {
+			assert.Equal("ping", name)
+		}
+	}
+}
+
+func TestMentionReplyQueriedOnlyForCommands(t *testing.T) {
+	c := NewCommandCenter(zap.NewNop())
+	c.SetMentionMode(MentionModeRequired)
+	called := 0
+	c.RegisterCommand(&testCommand{name: "ping", mode: CmdNameModeNormal, onCommand: func(*ParseResult) { called++ }})
+	e := newTestGroupMessageEvent("[CQ:reply,id=5]hello", 10, 1)
+	e.SelfId = 99
+	// 连接为 nil，查询被回复的消息将会 panic
+	e.setApiSender(api.NewSender(zap.NewNop(), nil, 1000))
+	assert.NotPanics(t, func() { c.onMessageRecv(e) })
+	e.RawMessage = "[CQ:reply,id=5][CQ:at,qq=99] ping"
+	assert.NotPanics(t, func() { c.onMessageRecv(e) })
+	assert.Equal(t, 1, called)
+}
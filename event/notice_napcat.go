@@ -0,0 +1,106 @@
+package event
+
+import "github.com/nekoite/go-napcat/qq"
+
+// NapCat 扩展的通知事件
+const (
+	NoticeEventTypeGroupCard         NoticeEventType = "group_card"
+	NoticeEventTypeEssence           NoticeEventType = "essence"
+	NoticeEventTypeGroupMsgEmojiLike NoticeEventType = "group_msg_emoji_like"
+	NoticeEventTypeOfflineFile       NoticeEventType = "offline_file"
+	NoticeEventTypeClientStatus      NoticeEventType = "client_status"
+	NoticeEventTypeBotOffline        NoticeEventType = "bot_offline"
+
+	NoticeEventSubtypeInputStatus NoticeEventSubtype = "input_status"
+	NoticeEventSubtypeProfileLike NoticeEventSubtype = "profile_like"
+	NoticeEventSubtypeTitle       NoticeEventSubtype = "title"
+	NoticeEventSubtypeGroupName   NoticeEventSubtype = "group_name"
+	NoticeEventSubtypeAdd         NoticeEventSubtype = "add"
+	NoticeEventSubtypeDelete      NoticeEventSubtype = "delete"
+)
+
+// NoticeEventGroupCard 群成员名片变更
+type NoticeEventGroupCard struct {
+	GroupNoticeEvent
+	CardNew string `json:"card_new"`
+	CardOld string `json:"card_old"`
+}
+
+// NoticeEventEssence 群精华消息变更，SubType 为 add 或 delete
+type NoticeEventEssence struct {
+	GroupNoticeEvent
+	MessageId  qq.MessageId `json:"message_id"`
+	SenderId   qq.UserId    `json:"sender_id"`
+	OperatorId qq.UserId    `json:"operator_id"`
+}
+
+type EmojiLike struct {
+	EmojiId string `json:"emoji_id"`
+	Count   int    `json:"count"`
+}
+
+// NoticeEventGroupMsgEmojiLike 群消息表情回应
+type NoticeEventGroupMsgEmojiLike struct {
+	GroupNoticeEvent
+	MessageId qq.MessageId `json:"message_id"`
+	Likes     []EmojiLike  `json:"likes"`
+}
+
+// NoticeEventInputStatus 对方正在输入。私聊时 GroupId 为 0
+type NoticeEventInputStatus struct {
+	NoticeEvent
+	GroupId    qq.GroupId `json:"group_id"`
+	StatusText string     `json:"status_text"`
+	// InputEventType 输入状态类型
+	InputEventType int `json:"event_type"`
+}
+
+// NoticeEventProfileLike 资料卡点赞
+type NoticeEventProfileLike struct {
+	NoticeEvent
+	OperatorId   qq.UserId `json:"operator_id"`
+	OperatorNick string    `json:"operator_nick"`
+	Times        int       `json:"times"`
+}
+
+// NoticeEventGroupTitle 群成员头衔变更
+type NoticeEventGroupTitle struct {
+	GroupNoticeEvent
+	Title string `json:"title"`
+}
+
+// NoticeEventGroupName 群名称变更
+type NoticeEventGroupName struct {
+	GroupNoticeEvent
+	NameNew string `json:"name_new"`
+}
+
+// NoticeEventOfflineFile 接收到离线文件
+type NoticeEventOfflineFile struct {
+	NoticeEvent
+	File struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+		Url  string `json:"url"`
+	} `json:"file"`
+}
+
+type ClientDevice struct {
+	AppId      int64  `json:"app_id"`
+	DeviceName string `json:"device_name"`
+	DeviceKind string `json:"device_kind"`
+}
+
+// NoticeEventClientStatus 其他客户端在线状态变更
+type NoticeEventClientStatus struct {
+	NoticeEvent
+	Client ClientDevice `json:"client"`
+	Online bool         `json:"online"`
+}
+
+// NoticeEventBotOffline 机器人账号下线
+type NoticeEventBotOffline struct {
+	NoticeEvent
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,117 @@
+package event
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/kong"
+)
+
+// commandParser 编译好的 kong 解析器及其绑定的参数结构体。
+// kong 的模型在创建时绑定到参数结构体上，所以一个解析器同时只能被一次调用使用。
+type commandParser struct {
+	kong *kong.Kong
+	args any
+}
+
+// parserPool 指令的解析器池。注册指令时创建第一个解析器，之后只在并发调用时创建更多的解析器。
+type parserPool struct {
+	cmd ICommand
+
+	mu   sync.Mutex
+	idle []*commandParser
+}
+
+func newParserPool(cmd ICommand) (*parserPool, error) {
+	p := &parserPool{cmd: cmd}
+	parser, err := newCommandParser(cmd)
+	if err != nil {
+		return nil, err
+	}
+	p.idle = append(p.idle, parser)
+	return p, nil
+}
+
+// newCommandParser 为指令创建新的参数结构体以及对应的 kong 解析器。
+func newCommandParser(cmd ICommand) (*commandParser, error) {
+	cmdName, _ := cmd.GetName()
+	options := []kong.Option{
+		kong.Exit(func(int) {}),
+		kong.Writers(io.Discard, io.Discard),
+		kong.Name(cmdName),
+	}
+	args := cmd.GetNew()
+	k, err := kong.New(args, append(options, cmd.GetOptions()...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &commandParser{kong: k, args: args}, nil
+}
+
+// get 取出一个空闲的解析器，使用完毕后需要调用 put 放回。
+func (p *parserPool) get() (*commandParser, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		parser := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return parser, nil
+	}
+	p.mu.Unlock()
+	return newCommandParser(p.cmd)
+}
+
+func (p *parserPool) put(parser *commandParser) {
+	parser.kong.Exit = func(int) {}
+	parser.kong.Stdout = io.Discard
+	parser.kong.Stderr = io.Discard
+	p.mu.Lock()
+	p.idle = append(p.idle, parser)
+	p.mu.Unlock()
+}
+
+// parserPool 返回指令的解析器池。没有注册的指令返回一个临时的解析器池。
+func (c *CommandCenter) parserPool(cmd ICommand) (*parserPool, error) {
+	if pool, ok := c.parsers[cmd]; ok {
+		return pool, nil
+	}
+	return newParserPool(cmd)
+}
+
+// copyArgs 将解析后的参数结构体复制到新分配的结构体中，使每次调用得到的参数互不影响。
+func copyArgs(args any) any {
+	v := reflect.ValueOf(args)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return args
+	}
+	fresh := reflect.New(v.Elem().Type())
+	fresh.Elem().Set(v.Elem())
+	return fresh.Interface()
+}
+
+// renderUsage 返回 kong 为指令（或其子指令）生成的用法，帮助文本与标题翻译为 locale。
+func (c *CommandCenter) renderUsage(cmd ICommand, subcommands []string, locale string) (string, error) {
+	pool, err := c.parserPool(cmd)
+	if err != nil {
+		return "", err
+	}
+	parser, err := pool.get()
+	if err != nil {
+		return "", err
+	}
+	defer pool.put(parser)
+	defer c.translateHelp(parser.kong, locale)()
+	stdout := strings.Builder{}
+	parser.kong.Stdout = &stdout
+	parser.kong.Stderr = &stdout
+	ctx, err := kong.Trace(parser.kong, subcommands)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.PrintUsage(false); err != nil {
+		return "", err
+	}
+	return c.translateUsage(stdout.String(), locale), nil
+}
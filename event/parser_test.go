@@ -0,0 +1,129 @@
+package event
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testDuplicateFlagArgs struct {
+	A string `name:"x"`
+	B string `name:"x"`
+}
+
+// testValueCommand 不可比较的指令类型
+type testValueCommand struct {
+	CommandBase
+	aliases []string
+}
+
+func (c testValueCommand) GetName() (string, CmdNameMode) {
+	return "value", CmdNameModeNormal
+}
+
+func TestRegisterCommandErrors(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	cases := []ICommand{
+		&testCommand{name: "nil", mode: CmdNameModeNormal, getNew: func() any { return nil }},
+		&testCommand{name: "dup", mode: CmdNameModeNormal, getNew: func() any { return &testDuplicateFlagArgs{} }},
+		&testCommand{name: "[invalid", mode: CmdNameModeRegex},
+		&testCommand{name: "mode", mode: CmdNameMode("unknown")},
+		testValueCommand{},
+	}
+	for _, cmd := range cases {
+		err := c.RegisterCommand(cmd)
+		assert.ErrorIs(err, errors.ErrInvalidCommand)
+	}
+	assert.Empty(c.Commands)
+	assert.Empty(c.RegexCommands)
+	assert.Empty(c.parsers)
+
+	assert.NoError(c.RegisterCommand(&testCommand{name: "ok", mode: CmdNameModeNormal}))
+	assert.Len(c.parsers, 1)
+}
+
+func TestParsedArgsAreFresh(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	var results []*testEchoArgs
+	cmd := &testCommand{name: "echo", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(p *ParseResult) {
+		results = append(results, p.ParsedArgs.(*testEchoArgs))
+	}}
+	assert.NoError(c.RegisterCommand(cmd))
+
+	c.onMessageRecv(newTestPrivateMessageEvent("echo a b"))
+	c.onMessageRecv(newTestPrivateMessageEvent("echo c"))
+	c.onMessageRecv(newTestPrivateMessageEvent("echo"))
+	if assert.Len(results, 3) {
+		assert.Equal([]string{"a", "b"}, results[0].Args)
+		assert.Equal([]string{"c"}, results[1].Args)
+		assert.Empty(results[2].Args)
+		assert.NotSame(results[0], results[1])
+	}
+	// 顺序调用时只使用注册时创建的解析器
+	assert.Len(c.parsers[cmd].idle, 1)
+}
+
+func TestParserPoolConcurrent(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	var mu sync.Mutex
+	got := make(map[string]bool)
+	start := make(chan struct{})
+	cmd := &testCommand{name: "echo", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(p *ParseResult) {
+		<-start
+		mu.Lock()
+		got[p.ParsedArgs.(*testEchoArgs).Args[0]] = true
+		mu.Unlock()
+	}}
+	assert.NoError(c.RegisterCommand(cmd))
+
+	wg := sync.WaitGroup{}
+	for _, arg := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.onMessageRecv(newTestPrivateMessageEvent("echo " + arg))
+		}()
+	}
+	close(start)
+	wg.Wait()
+	assert.Equal(map[string]bool{"a": true, "b": true, "c": true, "d": true}, got)
+	assert.LessOrEqual(len(c.parsers[cmd].idle), 4)
+}
+
+func benchmarkCommand() *testCommand {
+	return &testCommand{name: "music", mode: CmdNameModeNormal, getNew: func() any { return &testMusicArgs{} }, onCommand: func(*ParseResult) {}}
+}
+
+func BenchmarkParseCached(b *testing.B) {
+	c := NewCommandCenter(zap.NewNop())
+	if err := c.RegisterCommand(benchmarkCommand()); err != nil {
+		b.Fatal(err)
+	}
+	e := newTestPrivateMessageEvent("music -p qq song")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.onMessageRecv(e)
+	}
+}
+
+// BenchmarkParseUncached 每次调用都创建 kong 解析器，作为对比
+func BenchmarkParseUncached(b *testing.B) {
+	cmd := benchmarkCommand()
+	args := getArgs("-p qq song", false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser, err := newCommandParser(cmd)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := parser.kong.Parse(args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
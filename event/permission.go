@@ -0,0 +1,153 @@
+package event
+
+import (
+	"sync"
+
+	"github.com/nekoite/go-napcat/qq"
+)
+
+// Permission 权限节点。除了内置的角色以外，可以使用任意字符串作为自定义权限节点，例如 "music.play"。
+type Permission string
+
+const (
+	// PermissionSuperuser 机器人超级用户，拥有所有权限
+	PermissionSuperuser Permission = "superuser"
+	// PermissionGroupOwner 群主
+	PermissionGroupOwner Permission = "group.owner"
+	// PermissionGroupAdmin 群管理员，群主也拥有此权限
+	PermissionGroupAdmin Permission = "group.admin"
+)
+
+// ICommandWithPermission 声明执行指令需要的权限。调用者需要拥有所有列出的权限。
+type ICommandWithPermission interface {
+	GetPermissions() []Permission
+}
+
+// PermissionManager 管理超级用户以及授予用户和群的自定义权限节点。
+type PermissionManager struct {
+	mu         sync.RWMutex
+	superusers map[qq.UserId]struct{}
+	users      map[qq.UserId]map[Permission]struct{}
+	groups     map[qq.GroupId]map[Permission]struct{}
+}
+
+func NewPermissionManager() *PermissionManager {
+	return &PermissionManager{
+		superusers: make(map[qq.UserId]struct{}),
+		users:      make(map[qq.UserId]map[Permission]struct{}),
+		groups:     make(map[qq.GroupId]map[Permission]struct{}),
+	}
+}
+
+func (m *PermissionManager) AddSuperuser(userIds ...qq.UserId) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range userIds {
+		m.superusers[id] = struct{}{}
+	}
+}
+
+func (m *PermissionManager) RemoveSuperuser(userId qq.UserId) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.superusers, userId)
+}
+
+func (m *PermissionManager) IsSuperuser(userId qq.UserId) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.superusers[userId]
+	return ok
+}
+
+// GrantUser 授予用户权限节点，在任何聊天中有效。
+func (m *PermissionManager) GrantUser(userId qq.UserId, perms ...Permission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant(m.users, userId, perms)
+}
+
+func (m *PermissionManager) RevokeUser(userId qq.UserId, perms ...Permission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revoke(m.users, userId, perms)
+}
+
+// GrantGroup 授予群权限节点，群内所有成员在该群中拥有此权限。
+func (m *PermissionManager) GrantGroup(groupId qq.GroupId, perms ...Permission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant(m.groups, groupId, perms)
+}
+
+func (m *PermissionManager) RevokeGroup(groupId qq.GroupId, perms ...Permission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revoke(m.groups, groupId, perms)
+}
+
+// Has 判断消息的发送者在消息所在的聊天中是否拥有权限。超级用户拥有所有权限。
+func (m *PermissionManager) Has(e IMessageEvent, perm Permission) bool {
+	userId := e.GetUserId()
+	if m.IsSuperuser(userId) {
+		return true
+	}
+	switch perm {
+	case PermissionSuperuser:
+		return false
+	case PermissionGroupOwner, PermissionGroupAdmin:
+		ge, ok := e.(*GroupMessageEvent)
+		if !ok {
+			return false
+		}
+		if ge.Sender.Role == qq.GroupRoleOwner {
+			return true
+		}
+		return perm == PermissionGroupAdmin && ge.Sender.Role == qq.GroupRoleAdmin
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.users[userId][perm]; ok {
+		return true
+	}
+	if groupId, ok := getGroupId(e); ok {
+		if _, ok := m.groups[groupId][perm]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll 判断是否拥有所有权限，返回第一个缺少的权限。
+func (m *PermissionManager) HasAll(e IMessageEvent, perms []Permission) (Permission, bool) {
+	for _, perm := range perms {
+		if !m.Has(e, perm) {
+			return perm, false
+		}
+	}
+	return "", true
+}
+
+func grant[K comparable](nodes map[K]map[Permission]struct{}, key K, perms []Permission) {
+	set, ok := nodes[key]
+	if !ok {
+		set = make(map[Permission]struct{})
+		nodes[key] = set
+	}
+	for _, perm := range perms {
+		set[perm] = struct{}{}
+	}
+}
+
+func revoke[K comparable](nodes map[K]map[Permission]struct{}, key K, perms []Permission) {
+	set, ok := nodes[key]
+	if !ok {
+		return
+	}
+	for _, perm := range perms {
+		delete(set, perm)
+	}
+	if len(set) == 0 {
+		delete(nodes, key)
+	}
+}
@@ -0,0 +1,99 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testPermCommand struct {
+	testCommand
+	perms []Permission
+}
+
+func (c *testPermCommand) GetPermissions() []Permission {
+	return c.perms
+}
+
+func newTestGroupMessageEventWithRole(raw string, groupId int64, userId int64, role qq.GroupRole) *GroupMessageEvent {
+	e := newTestGroupMessageEvent(raw, groupId, userId)
+	e.Sender.UserId = qq.UserId(userId)
+	e.Sender.Role = role
+	return e
+}
+
+func TestPermissionRoles(t *testing.T) {
+	assert := assert.New(t)
+	m := NewPermissionManager()
+	m.AddSuperuser(1)
+
+	super := newTestGroupMessageEventWithRole("", 10, 1, qq.GroupRoleMember)
+	owner := newTestGroupMessageEventWithRole("", 10, 2, qq.GroupRoleOwner)
+	admin := newTestGroupMessageEventWithRole("", 10, 3, qq.GroupRoleAdmin)
+	member := newTestGroupMessageEventWithRole("", 10, 4, qq.GroupRoleMember)
+	private := newTestPrivateMessageEvent("")
+	private.UserId = 3
+
+	assert.True(m.Has(super, PermissionSuperuser))
+	assert.True(m.Has(super, PermissionGroupOwner))
+	assert.True(m.Has(super, "anything"))
+	assert.False(m.Has(owner, PermissionSuperuser))
+	assert.True(m.Has(owner, PermissionGroupOwner))
+	assert.True(m.Has(owner, PermissionGroupAdmin))
+	assert.False(m.Has(admin, PermissionGroupOwner))
+	assert.True(m.Has(admin, PermissionGroupAdmin))
+	assert.False(m.Has(member, PermissionGroupAdmin))
+	assert.False(m.Has(private, PermissionGroupAdmin))
+}
+
+func TestPermissionNodes(t *testing.T) {
+	assert := assert.New(t)
+	m := NewPermissionManager()
+	inGroup := newTestGroupMessageEvent("", 10, 4)
+	otherGroup := newTestGroupMessageEvent("", 11, 4)
+	otherUser := newTestGroupMessageEvent("", 10, 5)
+
+	assert.False(m.Has(inGroup, "music.play"))
+	m.GrantUser(4, "music.play")
+	assert.True(m.Has(inGroup, "music.play"))
+	assert.True(m.Has(otherGroup, "music.play"))
+	assert.False(m.Has(otherUser, "music.play"))
+	m.RevokeUser(4, "music.play")
+	assert.False(m.Has(inGroup, "music.play"))
+
+	m.GrantGroup(10, "music.play", "music.search")
+	assert.True(m.Has(inGroup, "music.play"))
+	assert.True(m.Has(otherUser, "music.search"))
+	assert.False(m.Has(otherGroup, "music.play"))
+
+	missing, ok := m.HasAll(inGroup, []Permission{"music.play", "music.admin"})
+	assert.False(ok)
+	assert.Equal(Permission("music.admin"), missing)
+	m.RevokeGroup(10, "music.play")
+	assert.False(m.Has(inGroup, "music.play"))
+	assert.True(m.Has(inGroup, "music.search"))
+}
+
+func TestCommandRequiresPermission(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	called := 0
+	d.RegisterCommand(&testPermCommand{
+		testCommand: testCommand{
+			name:      "ban",
+			mode:      CmdNameModeNormal,
+			getNew:    func() any { return &struct{}{} },
+			onCommand: func(parseResult *ParseResult) { called++ },
+		},
+		perms: []Permission{PermissionGroupAdmin},
+	})
+	d.Dispatch(newTestGroupMessageEventWithRole("ban", 10, 4, qq.GroupRoleMember))
+	assert.Equal(0, called)
+	d.Dispatch(newTestGroupMessageEventWithRole("ban", 10, 3, qq.GroupRoleAdmin))
+	assert.Equal(1, called)
+	d.Permissions().AddSuperuser(4)
+	d.Dispatch(newTestGroupMessageEventWithRole("ban", 10, 4, qq.GroupRoleMember))
+	assert.Equal(2, called)
+}
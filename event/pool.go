@@ -0,0 +1,175 @@
+package event
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OverflowPolicy 工作池队列已满时的处理策略
+type OverflowPolicy string
+
+const (
+	// OverflowPolicyBlock 阻塞直到队列有空位，最多等待 blockTimeout，超时后丢弃新的事件。
+	// 等待期间 WebSocket 的读取也被阻塞，API 调用的响应无法送达，所以必须设置等待时间，并且应当远小于 API 的超时时间
+	OverflowPolicyBlock OverflowPolicy = "block"
+	// OverflowPolicyDropNewest 丢弃新的事件
+	OverflowPolicyDropNewest OverflowPolicy = "drop_newest"
+	// OverflowPolicyDropOldest 丢弃队列中最旧的事件
+	OverflowPolicyDropOldest OverflowPolicy = "drop_oldest"
+)
+
+// WorkerPool 按会话分配任务的工作池。相同键的任务总是由同一个工作协程按提交顺序依次执行，
+// 不同的键被散列到不同的工作协程上并行执行。
+type WorkerPool struct {
+	logger *zap.Logger
+	policy OverflowPolicy
+	// blockTimeout 阻塞策略下等待队列空位的最长时间
+	blockTimeout time.Duration
+	queues       []chan func()
+	// sendMu 保证同一队列的提交（以及丢弃最旧任务）是互斥的
+	sendMu []sync.Mutex
+	// closeMu 防止在关闭后继续向队列发送任务
+	closeMu sync.RWMutex
+	closed  bool
+	// done 在关闭时关闭，使阻塞中的提交返回
+	done chan struct{}
+	// senders 阻塞中的提交，队列需要在它们返回后才能关闭
+	senders sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// NewWorkerPool 创建并启动一个工作池。workers 为工作协程数量，queueSize 为每个工作协程的队列长度，
+// blockTimeout 为阻塞策略下等待队列空位的最长时间。阻塞策略的 blockTimeout 小于等于 0 时使用 drop_newest。
+func NewWorkerPool(logger *zap.Logger, workers int, queueSize int, policy OverflowPolicy, blockTimeout time.Duration) *WorkerPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	switch policy {
+	case OverflowPolicyBlock:
+		if blockTimeout <= 0 {
+			logger.Warn("block overflow policy requires a block timeout, using drop_newest")
+			policy = OverflowPolicyDropNewest
+		}
+	case OverflowPolicyDropNewest, OverflowPolicyDropOldest:
+	default:
+		logger.Warn("unknown overflow policy, using drop_newest", zap.String("policy", string(policy)))
+		policy = OverflowPolicyDropNewest
+	}
+	p := &WorkerPool{
+		logger:       logger.Named("pool"),
+		policy:       policy,
+		blockTimeout: blockTimeout,
+		queues:       make([]chan func(), workers),
+		sendMu:       make([]sync.Mutex, workers),
+		done:         make(chan struct{}),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan func(), queueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *WorkerPool) work(queue chan func()) {
+	defer p.wg.Done()
+	for task := range queue {
+		task()
+	}
+}
+
+// Submit 提交一个任务，返回任务是否被接受。任务被丢弃或工作池已关闭时返回 false。
+// 阻塞策略下，等待超时或者等待期间工作池被关闭时同样返回 false。
+func (p *WorkerPool) Submit(key string, task func()) bool {
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return false
+	}
+	idx := p.indexOf(key)
+	queue := p.queues[idx]
+	if p.policy == OverflowPolicyBlock {
+		// 阻塞时不能持有 closeMu，否则 Close 会一直等待队列出现空位
+		p.senders.Add(1)
+		p.closeMu.RUnlock()
+		defer p.senders.Done()
+		timer := time.NewTimer(p.blockTimeout)
+		defer timer.Stop()
+		select {
+		case queue <- task:
+			return true
+		case <-p.done:
+			p.logger.Warn("pool closed while waiting for queue", zap.String("key", key))
+			return false
+		case <-timer.C:
+			p.logger.Warn("queue full after waiting, dropped newest task", zap.String("key", key), zap.Duration("timeout", p.blockTimeout))
+			return false
+		}
+	}
+	defer p.closeMu.RUnlock()
+	switch p.policy {
+	case OverflowPolicyDropOldest:
+		p.sendMu[idx].Lock()
+		defer p.sendMu[idx].Unlock()
+		for {
+			select {
+			case queue <- task:
+				return true
+			default:
+			}
+			select {
+			case <-queue:
+				p.logger.Warn("queue full, dropped oldest task", zap.String("key", key))
+			default:
+			}
+		}
+	default:
+		select {
+		case queue <- task:
+			return true
+		default:
+			p.logger.Warn("queue full, dropped newest task", zap.String("key", key))
+			return false
+		}
+	}
+}
+
+// Close 停止接受新任务，并等待已提交的任务执行完毕。
+func (p *WorkerPool) Close() {
+	p.closeMu.Lock()
+	if p.closed {
+		p.closeMu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	p.closeMu.Unlock()
+	p.senders.Wait()
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) indexOf(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// ConversationKey 返回事件所属会话的键。群事件以群号区分，其余与用户相关的事件以用户 QQ 区分。
+func ConversationKey(event IEvent) string {
+	if e, ok := event.(IGroupEvent); ok {
+		return "group:" + e.GetGroupId().String()
+	}
+	if e, ok := event.(IUserEvent); ok {
+		return "user:" + e.GetUserId().String()
+	}
+	return string(event.GetEventType())
+}
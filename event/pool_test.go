@@ -0,0 +1,169 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWorkerPoolOrderedPerKey(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 4, 100, OverflowPolicyBlock, time.Second)
+	mu := sync.Mutex{}
+	results := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"a", "b", "c"} {
+			p.Submit(key, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				results[key] = append(results[key], i)
+			})
+		}
+	}
+	p.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Len(results[key], 100)
+		for i, v := range results[key] {
+			assert.Equal(i, v)
+		}
+	}
+}
+
+func TestWorkerPoolDropNewest(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 1, 1, OverflowPolicyDropNewest, 0)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	executed := make([]int, 0)
+	assert.True(p.Submit("a", func() {
+		close(started)
+		<-block
+	}))
+	<-started
+	assert.True(p.Submit("a", func() { executed = append(executed, 1) }))
+	assert.False(p.Submit("a", func() { executed = append(executed, 2) }))
+	close(block)
+	p.Close()
+	assert.Equal([]int{1}, executed)
+}
+
+func TestWorkerPoolDropOldest(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 1, 1, OverflowPolicyDropOldest, 0)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	executed := make([]int, 0)
+	assert.True(p.Submit("a", func() {
+		close(started)
+		<-block
+	}))
+	<-started
+	assert.True(p.Submit("a", func() { executed = append(executed, 1) }))
+	assert.True(p.Submit("a", func() { executed = append(executed, 2) }))
+	close(block)
+	p.Close()
+	assert.Equal([]int{2}, executed)
+}
+
+func TestWorkerPoolBlockTimeout(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 1, 1, OverflowPolicyBlock, 20*time.Millisecond)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	executed := make([]int, 0)
+	assert.True(p.Submit("a", func() {
+		close(started)
+		<-block
+	}))
+	<-started
+	assert.True(p.Submit("a", func() { executed = append(executed, 1) }))
+	start := time.Now()
+	// 队列一直是满的，等待超时后丢弃新的任务
+	assert.False(p.Submit("a", func() { executed = append(executed, 2) }))
+	assert.GreaterOrEqual(time.Since(start), 20*time.Millisecond)
+	close(block)
+	p.Close()
+	assert.Equal([]int{1}, executed)
+}
+
+func TestWorkerPoolBlockWithoutTimeout(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 1, 1, OverflowPolicyBlock, 0)
+	assert.Equal(OverflowPolicyDropNewest, p.policy)
+	p.Close()
+}
+
+func TestWorkerPoolClosed(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 2, 1, OverflowPolicyBlock, time.Second)
+	p.Close()
+	assert.False(p.Submit("a", func() {}))
+	assert.NotPanics(p.Close)
+}
+
+func TestWorkerPoolCloseWhileSubmitBlocked(t *testing.T) {
+	assert := assert.New(t)
+	p := NewWorkerPool(zap.NewNop(), 1, 1, OverflowPolicyBlock, time.Second)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	executed := make([]int, 0)
+	assert.True(p.Submit("a", func() {
+		close(started)
+		<-block
+	}))
+	<-started
+	assert.True(p.Submit("a", func() { executed = append(executed, 1) }))
+	submitted := make(chan bool)
+	go func() {
+		submitted <- p.Submit("a", func() { executed = append(executed, 2) })
+	}()
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	// 关闭使阻塞中的提交返回，而不是等待队列出现空位
+	select {
+	case ok := <-submitted:
+		assert.False(ok)
+	case <-time.After(time.Second):
+		t.Fatal("blocked submit was not released by Close")
+	}
+	close(block)
+	<-closed
+	assert.Equal([]int{1}, executed)
+}
+
+func TestConversationKey(t *testing.T) {
+	assert := assert.New(t)
+	pe := newTestPrivateMessageEvent("hello")
+	pe.UserId = 123
+	assert.Equal("user:123", ConversationKey(pe))
+	ge := &GroupMessageEvent{}
+	ge.UserId = 123
+	ge.GroupId = 456
+	assert.Equal("group:456", ConversationKey(ge))
+	me := &MetaEvent{}
+	me.EventType = EventTypeMeta
+	assert.Equal("meta_event", ConversationKey(me))
+}
+
+func TestDispatchWithWorkerPoolPreventDefault(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), true)
+	d.SetWorkerPool(NewWorkerPool(zap.NewNop(), 2, 10, OverflowPolicyBlock, time.Second))
+	assert.True(d.IsAsync())
+	called := false
+	d.RegisterHandlerAllTypes(func(event IEvent) {
+		event.PreventDefault()
+	})
+	d.RegisterHandlerPrivateMessage(func(event IEvent) {
+		called = true
+	})
+	d.Dispatch(newTestPrivateMessageEvent("hello"))
+	d.Close()
+	assert.False(called)
+}
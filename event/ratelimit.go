@@ -0,0 +1,114 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit 指令的频率限制。为零的项表示不限制。
+type RateLimit struct {
+	// UserCooldown 同一用户两次调用之间的冷却时间
+	UserCooldown time.Duration
+	// GroupCooldown 同一群内两次调用之间的冷却时间，私聊中无效
+	GroupCooldown time.Duration
+	// GlobalCooldown 所有调用之间的冷却时间
+	GlobalCooldown time.Duration
+	// DailyQuota 每个用户每天最多调用的次数，按本地时间零点重置
+	DailyQuota int
+}
+
+// ICommandWithRateLimit 声明指令的频率限制。超级用户不受限制。
+type ICommandWithRateLimit interface {
+	GetRateLimit() RateLimit
+}
+
+// RateLimitResult 频率限制检查的结果
+type RateLimitResult struct {
+	Allowed bool
+	// Wait 冷却中时需要等待的时间
+	Wait time.Duration
+	// QuotaExceeded 今天的调用次数已经用完
+	QuotaExceeded bool
+}
+
+// WaitSeconds 返回需要等待的秒数，向上取整。
+func (r RateLimitResult) WaitSeconds() int {
+	return int((r.Wait + time.Second - 1) / time.Second)
+}
+
+// RateLimiter 记录指令的调用情况。
+type RateLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	until   map[string]time.Time
+	quota   map[string]int
+	today   string
+	inserts int
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		now:   time.Now,
+		until: make(map[string]time.Time),
+		quota: make(map[string]int),
+	}
+}
+
+// Take 检查指令是否可以被 e 的发送者调用。可以调用时记录这次调用，否则不做任何记录。
+func (r *RateLimiter) Take(name string, e IMessageEvent, limit RateLimit) RateLimitResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	userKey := fmt.Sprintf("%s|user:%d", name, e.GetUserId())
+	cooldowns := make(map[string]time.Duration, 3)
+	if limit.UserCooldown > 0 {
+		cooldowns[userKey] = limit.UserCooldown
+	}
+	if groupId, ok := getGroupId(e); ok && limit.GroupCooldown > 0 {
+		cooldowns[fmt.Sprintf("%s|group:%d", name, groupId)] = limit.GroupCooldown
+	}
+	if limit.GlobalCooldown > 0 {
+		cooldowns[name+"|global"] = limit.GlobalCooldown
+	}
+	var wait time.Duration
+	for key := range cooldowns {
+		if until, ok := r.until[key]; ok && until.After(now) && until.Sub(now) > wait {
+			wait = until.Sub(now)
+		}
+	}
+	if wait > 0 {
+		return RateLimitResult{Wait: wait}
+	}
+	// 日期变化时重置所有次数
+	day := now.Format(time.DateOnly)
+	if day != r.today {
+		r.today = day
+		clear(r.quota)
+	}
+	if limit.DailyQuota > 0 && r.quota[userKey] >= limit.DailyQuota {
+		return RateLimitResult{QuotaExceeded: true}
+	}
+	for key, cooldown := range cooldowns {
+		r.until[key] = now.Add(cooldown)
+	}
+	if limit.DailyQuota > 0 {
+		r.quota[userKey]++
+	}
+	r.inserts++
+	if r.inserts >= 1024 {
+		r.inserts = 0
+		for key, until := range r.until {
+			if !until.After(now) {
+				delete(r.until, key)
+			}
+		}
+	}
+	return RateLimitResult{Allowed: true}
+}
+
+// formatCooldownReply 将回复模板中的 {seconds} 替换为需要等待的秒数。
+func formatCooldownReply(format string, result RateLimitResult) string {
+	return strings.ReplaceAll(format, "{seconds}", fmt.Sprint(result.WaitSeconds()))
+}
@@ -0,0 +1,122 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testRateLimitCommand struct {
+	testCommand
+	limit RateLimit
+}
+
+func (c *testRateLimitCommand) GetRateLimit() RateLimit {
+	return c.limit
+}
+
+func newTestRateLimiter(now *time.Time) *RateLimiter {
+	r := NewRateLimiter()
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestRateLimiterUserAndGroupCooldown(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	r := newTestRateLimiter(&now)
+	limit := RateLimit{UserCooldown: 10 * time.Second, GroupCooldown: 3 * time.Second}
+
+	assert.True(r.Take("music", newTestGroupMessageEvent("", 10, 1), limit).Allowed)
+	res := r.Take("music", newTestGroupMessageEvent("", 10, 2), limit)
+	assert.False(res.Allowed)
+	assert.Equal(3*time.Second, res.Wait)
+	assert.Equal(3, res.WaitSeconds())
+	assert.True(r.Take("music", newTestGroupMessageEvent("", 11, 2), limit).Allowed)
+	assert.True(r.Take("other", newTestGroupMessageEvent("", 10, 1), limit).Allowed)
+
+	now = now.Add(4 * time.Second)
+	assert.True(r.Take("music", newTestGroupMessageEvent("", 10, 3), limit).Allowed)
+	res = r.Take("music", newTestGroupMessageEvent("", 12, 1), limit)
+	assert.False(res.Allowed)
+	assert.Equal(6*time.Second, res.Wait)
+}
+
+func TestRateLimiterGlobalCooldown(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	r := newTestRateLimiter(&now)
+	limit := RateLimit{GlobalCooldown: 1500 * time.Millisecond}
+	assert.True(r.Take("music", newTestGroupMessageEvent("", 10, 1), limit).Allowed)
+	res := r.Take("music", newTestGroupMessageEvent("", 11, 2), limit)
+	assert.False(res.Allowed)
+	assert.Equal(2, res.WaitSeconds())
+	now = now.Add(1500 * time.Millisecond)
+	assert.True(r.Take("music", newTestGroupMessageEvent("", 11, 2), limit).Allowed)
+}
+
+func TestRateLimiterDailyQuota(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local)
+	r := newTestRateLimiter(&now)
+	limit := RateLimit{DailyQuota: 2}
+	e := newTestGroupMessageEvent("", 10, 1)
+	assert.True(r.Take("music", e, limit).Allowed)
+	assert.True(r.Take("music", e, limit).Allowed)
+	res := r.Take("music", e, limit)
+	assert.False(res.Allowed)
+	assert.True(res.QuotaExceeded)
+	assert.True(r.Take("music", newTestGroupMessageEvent("", 10, 2), limit).Allowed)
+	now = now.Add(2 * time.Hour)
+	assert.True(r.Take("music", e, limit).Allowed)
+}
+
+func TestCommandRateLimited(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	called := 0
+	d.RegisterCommand(&testRateLimitCommand{
+		testCommand: testCommand{
+			name:      "music",
+			mode:      CmdNameModeNormal,
+			getNew:    func() any { return &struct{}{} },
+			onCommand: func(parseResult *ParseResult) { called++ },
+		},
+		limit: RateLimit{UserCooldown: time.Hour},
+	})
+	d.Dispatch(newTestGroupMessageEvent("music", 10, 1))
+	d.Dispatch(newTestGroupMessageEvent("music", 10, 1))
+	assert.Equal(1, called)
+	d.Permissions().AddSuperuser(1)
+	d.Dispatch(newTestGroupMessageEvent("music", 10, 1))
+	assert.Equal(2, called)
+}
+
+func TestParseFailureDoesNotConsumeRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	var results []error
+	c.RegisterCommand(&testRateLimitCommand{
+		testCommand: testCommand{
+			name:      "music",
+			mode:      CmdNameModeNormal,
+			getNew:    func() any { return &testMusicArgs{} },
+			onCommand: func(parseResult *ParseResult) { results = append(results, parseResult.Error) },
+		},
+		limit: RateLimit{UserCooldown: time.Hour, DailyQuota: 1},
+	})
+	c.onMessageRecv(newTestGroupMessageEvent("music", 10, 1))
+	c.onMessageRecv(newTestGroupMessageEvent("music --help", 10, 1))
+	c.onMessageRecv(newTestGroupMessageEvent("music song", 10, 1))
+	c.onMessageRecv(newTestGroupMessageEvent("music song", 10, 1))
+	if assert.Len(results, 3) {
+		assert.Error(results[0])
+		assert.NoError(results[2])
+	}
+}
+
+func TestFormatCooldownReply(t *testing.T) {
+	assert.Equal(t, "请等待 3 秒", formatCooldownReply("请等待 {seconds} 秒", RateLimitResult{Wait: 2100 * time.Millisecond}))
+}
@@ -0,0 +1,108 @@
+package event
+
+import (
+	"sync"
+
+	"github.com/nekoite/go-napcat/errors"
+)
+
+// NewEventFunc 用于创建解析事件时使用的空事件，应该返回结构体指针
+type NewEventFunc func() IEvent
+
+// EventKey 事件解析的键。
+//
+// DetailType 为具体的事件类型，对应 message_type，notice_type，request_type 或 meta_event_type 字段。
+// 查找时依次尝试 (PostType, DetailType, SubType)，(PostType, DetailType, "") 与 (PostType, "", "")。
+type EventKey struct {
+	PostType   EventType
+	DetailType string
+	SubType    string
+}
+
+var (
+	eventParsersMu sync.RWMutex
+	eventParsers   = map[EventKey]NewEventFunc{
+		noticeEventKey(NoticeEventTypeGroupUpload, ""):                     func() IEvent { return new(NoticeEventGroupUpload) },
+		noticeEventKey(NoticeEventTypeGroupIncrease, ""):                   func() IEvent { return new(NoticeEventGroupOperation) },
+		noticeEventKey(NoticeEventTypeGroupDecrease, ""):                   func() IEvent { return new(NoticeEventGroupOperation) },
+		noticeEventKey(NoticeEventTypeGroupBan, ""):                        func() IEvent { return new(NoticeEventGroupBan) },
+		noticeEventKey(NoticeEventTypeGroupRecall, ""):                     func() IEvent { return new(NoticeEventGroupRecall) },
+		noticeEventKey(NoticeEventTypeFriendRecall, ""):                    func() IEvent { return new(NoticeEventFriendRecall) },
+		noticeEventKey(NoticeEventTypeGroupAdmin, ""):                      func() IEvent { return new(GroupNoticeEvent) },
+		noticeEventKey(NoticeEventTypeFriendAdd, ""):                       func() IEvent { return new(NoticeEventFriendAdd) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypeHonor):     func() IEvent { return new(NoticeEventGroupHonor) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypeLuckyKing): func() IEvent { return new(NoticeEventGroupNotify) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypePoke):      func() IEvent { return new(NoticeEventGroupNotify) },
+
+		noticeEventKey(NoticeEventTypeGroupCard, ""):                         func() IEvent { return new(NoticeEventGroupCard) },
+		noticeEventKey(NoticeEventTypeEssence, ""):                           func() IEvent { return new(NoticeEventEssence) },
+		noticeEventKey(NoticeEventTypeGroupMsgEmojiLike, ""):                 func() IEvent { return new(NoticeEventGroupMsgEmojiLike) },
+		noticeEventKey(NoticeEventTypeOfflineFile, ""):                       func() IEvent { return new(NoticeEventOfflineFile) },
+		noticeEventKey(NoticeEventTypeClientStatus, ""):                      func() IEvent { return new(NoticeEventClientStatus) },
+		noticeEventKey(NoticeEventTypeBotOffline, ""):                        func() IEvent { return new(NoticeEventBotOffline) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypeInputStatus): func() IEvent { return new(NoticeEventInputStatus) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypeProfileLike): func() IEvent { return new(NoticeEventProfileLike) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypeTitle):       func() IEvent { return new(NoticeEventGroupTitle) },
+		noticeEventKey(NoticeEventTypeNotify, NoticeEventSubtypeGroupName):   func() IEvent { return new(NoticeEventGroupName) },
+
+		{EventTypeMessage, string(MessageEventTypePrivate), ""}:     func() IEvent { return new(PrivateMessageEvent) },
+		{EventTypeMessage, string(MessageEventTypeGroup), ""}:       func() IEvent { return new(GroupMessageEvent) },
+		{EventTypeMessageSent, string(MessageEventTypePrivate), ""}: func() IEvent { return new(PrivateMessageSentEvent) },
+		{EventTypeMessageSent, string(MessageEventTypeGroup), ""}:   func() IEvent { return new(GroupMessageSentEvent) },
+
+		{EventTypeRequest, string(RequestEventTypeFriend), ""}: func() IEvent { return new(FriendRequestEvent) },
+		{EventTypeRequest, string(RequestEventTypeGroup), ""}:  func() IEvent { return new(GroupRequestEvent) },
+
+		{EventTypeMeta, "", ""}: func() IEvent { return new(MetaEvent) },
+	}
+)
+
+func noticeEventKey(noticeType NoticeEventType, subType NoticeEventSubtype) EventKey {
+	return EventKey{EventTypeNotice, string(noticeType), string(subType)}
+}
+
+// RegisterEvent 注册事件的解析。已经注册过的键不能重复注册，但可以注册更具体的键（例如带有子类型的键）来覆盖较宽泛的键。
+func RegisterEvent(key EventKey, newFunc NewEventFunc) error {
+	eventParsersMu.Lock()
+	defer eventParsersMu.Unlock()
+	if _, ok := eventParsers[key]; ok {
+		return errors.ErrEventAlreadyRegistered
+	}
+	eventParsers[key] = newFunc
+	return nil
+}
+
+// RegisterNoticeEvent 注册通知事件的解析。subType 为空字符串时匹配该通知类型下所有没有单独注册的子类型。
+// 已经注册过的类型不能重复注册。
+func RegisterNoticeEvent(noticeType NoticeEventType, subType NoticeEventSubtype, newFunc NewEventFunc) error {
+	return RegisterEvent(noticeEventKey(noticeType, subType), newFunc)
+}
+
+func getEventParser(key EventKey) (NewEventFunc, bool) {
+	eventParsersMu.RLock()
+	defer eventParsersMu.RUnlock()
+	if f, ok := eventParsers[key]; ok {
+		return f, true
+	}
+	if f, ok := eventParsers[EventKey{key.PostType, key.DetailType, ""}]; ok {
+		return f, true
+	}
+	f, ok := eventParsers[EventKey{key.PostType, "", ""}]
+	return f, ok
+}
+
+// newFallbackEvent 创建没有注册解析的事件使用的事件。
+func newFallbackEvent(key EventKey) (IEvent, error) {
+	switch key.PostType {
+	case EventTypeMessage, EventTypeMessageSent:
+		return new(MessageEvent), errors.ErrUnknownMessageEvent
+	case EventTypeNotice:
+		return new(NoticeEvent), errors.ErrUnknownNoticeEvent
+	case EventTypeRequest:
+		return new(RequestEvent), errors.ErrUnknownRequestEvent
+	case EventTypeMeta:
+		return new(MetaEvent), errors.ErrUnknownMetaEvent
+	default:
+		return &UnknownEvent{DetailType: key.DetailType}, errors.ErrUnknownEvent
+	}
+}
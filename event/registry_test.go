@@ -0,0 +1,60 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCustomPostEvent struct {
+	BaseEvent
+	Value int `json:"value"`
+}
+
+type testSubTypeEvent struct {
+	MetaEvent
+	Extra string `json:"extra"`
+}
+
+func TestParseUnknownEventKeepsRaw(t *testing.T) {
+	assert := assert.New(t)
+	raw := []byte(`{"time":1,"self_id":2,"post_type":"test_unknown","sub_type":"x","nested":{"field":"v"}}`)
+	e, err := ParseEvent(raw, nil)
+	assert.ErrorIs(err, errors.ErrUnknownEvent)
+	ue := GetAs[UnknownEvent](e)
+	assert.NotNil(ue)
+	assert.Equal("x", ue.SubType)
+	assert.Equal(raw, e.RawJSON())
+	assert.Equal("v", ue.RawField("nested.field").String())
+}
+
+func TestRegisterEventCustomPostType(t *testing.T) {
+	assert := assert.New(t)
+	key := EventKey{PostType: "test_custom_post"}
+	assert.Nil(RegisterEvent(key, func() IEvent { return new(testCustomPostEvent) }))
+	assert.ErrorIs(RegisterEvent(key, func() IEvent { return new(testCustomPostEvent) }), errors.ErrEventAlreadyRegistered)
+	e, err := ParseEvent([]byte(`{"post_type":"test_custom_post","value":3}`), nil)
+	assert.Nil(err)
+	assert.Equal(3, GetAs[testCustomPostEvent](e).Value)
+}
+
+func TestRegisterEventMoreSpecificKey(t *testing.T) {
+	assert := assert.New(t)
+	assert.ErrorIs(RegisterEvent(EventKey{PostType: EventTypeMeta}, func() IEvent { return new(MetaEvent) }), errors.ErrEventAlreadyRegistered)
+	assert.Nil(RegisterEvent(EventKey{EventTypeMeta, "test_meta", "sub"}, func() IEvent { return new(testSubTypeEvent) }))
+	e, err := ParseEvent([]byte(`{"post_type":"meta_event","meta_event_type":"test_meta","sub_type":"sub","extra":"e"}`), nil)
+	assert.Nil(err)
+	assert.Equal("e", GetAs[testSubTypeEvent](e).Extra)
+	e, err = ParseEvent([]byte(`{"post_type":"meta_event","meta_event_type":"test_meta","sub_type":"other"}`), nil)
+	assert.Nil(err)
+	assert.IsType(&MetaEvent{}, e)
+}
+
+func TestParseUnknownMessageEvent(t *testing.T) {
+	assert := assert.New(t)
+	e, err := ParseEvent([]byte(`{"post_type":"message","message_type":"guild","user_id":1}`), nil)
+	assert.ErrorIs(err, errors.ErrUnknownMessageEvent)
+	assert.IsType(&MessageEvent{}, e)
+	assert.Equal("guild", e.(*MessageEvent).RawField("message_type").String())
+}
@@ -0,0 +1,128 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nekoite/go-napcat/i18n"
+	"github.com/nekoite/go-napcat/message"
+	"go.uber.org/zap"
+)
+
+// ReplyPolicy 指令中心自动回复的方式，用于参数解析错误和指令的返回值。
+type ReplyPolicy struct {
+	// ParseError 为 true 时由指令中心回复参数解析错误（或 --help 的输出）及用法，不再调用指令
+	ParseError bool
+	// Quote 回复时是否引用指令消息
+	Quote bool
+	// ForwardThreshold 回复的文本超过这个字符数时以合并转发消息发送，小于等于 0 时不使用
+	ForwardThreshold int
+	// ForwardNickname 合并转发消息中节点的昵称，可以是消息目录中的键
+	ForwardNickname string
+}
+
+// DefaultReplyPolicy 默认的自动回复方式：引用指令消息，不处理参数解析错误，不使用合并转发消息。
+func DefaultReplyPolicy() ReplyPolicy {
+	return ReplyPolicy{
+		Quote:           true,
+		ForwardNickname: "command.forward_nickname",
+	}
+}
+
+// ICommandWithResult 实现此接口的指令由指令中心调用 Execute 代替 OnCommand，返回值会被自动回复：
+// *message.Chain 和 message.Segment 原样发送，string 作为文本发送，error 发送错误信息，nil 不回复。
+type ICommandWithResult interface {
+	Execute(parseResult *ParseResult) any
+}
+
+// ICommandHandlesParseError 实现此接口并返回 true 的指令自行处理参数解析错误，不使用 ReplyPolicy.ParseError。
+type ICommandHandlesParseError interface {
+	HandlesParseError() bool
+}
+
+// ReplyPolicy 返回当前的自动回复方式。
+func (c *CommandCenter) ReplyPolicy() ReplyPolicy {
+	return c.replyPolicy
+}
+
+// SetReplyPolicy 设置参数解析错误和指令返回值的自动回复方式。
+func (c *CommandCenter) SetReplyPolicy(policy ReplyPolicy) {
+	c.replyPolicy = policy
+}
+
+// handlesParseError 返回指令中心是否需要代替指令处理这次的参数解析错误。
+func (c *CommandCenter) handlesParseError(cmd ICommand, parseResult *ParseResult) bool {
+	if !c.replyPolicy.ParseError {
+		return false
+	}
+	if h, ok := cmd.(ICommandHandlesParseError); ok && h.HandlesParseError() {
+		return false
+	}
+	return parseResult.Error != nil || parseResult.StdOut != ""
+}
+
+// formatParseError 将参数解析错误和指令的用法整理成回复的文本。
+// 带 --help 参数时 kong 已经输出了用法（之后仍可能报告缺少参数），直接使用该输出。
+func (c *CommandCenter) formatParseError(cmd ICommand, parseResult *ParseResult) string {
+	if parseResult.StdOut != "" {
+		return strings.TrimSpace(parseResult.StdOut)
+	}
+	text := c.catalog.Translate(parseResult.Locale, "command.parse_error", i18n.Args{"error": parseResult.Error.Error()})
+	usage, err := c.renderUsage(cmd, strings.Fields(parseResult.Subcommand), parseResult.Locale)
+	if err != nil {
+		return text
+	}
+	return text + "\n" + strings.TrimSpace(usage)
+}
+
+// resultChain 将指令的返回值转换为回复的消息，返回值不需要回复时返回 nil。
+func (c *CommandCenter) resultChain(event IMessageEvent, result any) (*message.Chain, bool) {
+	switch r := result.(type) {
+	case nil:
+		return nil, false
+	case *message.Chain:
+		if r == nil || len(r.Messages) == 0 {
+			return nil, false
+		}
+		return r, c.replyPolicy.Quote
+	case message.Segment:
+		return r.AsChain(), c.replyPolicy.Quote
+	case string:
+		return c.textChain(event, r)
+	case error:
+		return c.textChain(event, r.Error())
+	default:
+		c.logger.Warn("unsupported command result type", zap.String("type", fmt.Sprintf("%T", result)))
+		return nil, false
+	}
+}
+
+// textChain 将文本转换为回复的消息，超过 ForwardThreshold 时使用合并转发消息。
+func (c *CommandCenter) textChain(event IMessageEvent, text string) (*message.Chain, bool) {
+	if text == "" {
+		return nil, false
+	}
+	threshold := c.replyPolicy.ForwardThreshold
+	if threshold > 0 && utf8.RuneCountInString(text) > threshold {
+		nickname := c.Translate(event, c.replyPolicy.ForwardNickname, nil)
+		return message.NewCustomNode(event.GetSelfId(), nickname, text).Segment().AsChain(), false
+	}
+	return message.NewText(text).Segment().AsChain(), c.replyPolicy.Quote
+}
+
+// sendResult 使用 ParseResult.Event 回复指令的返回值，指令超时或机器人关闭后返回的结果不会被发送。
+func (c *CommandCenter) sendResult(event IMessageEvent, result any) {
+	chain, quote := c.resultChain(event, result)
+	if chain == nil {
+		return
+	}
+	_, err := event.Reply(chain, quote)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		c.logger.Warn("command result discarded", zap.Error(err))
+	} else if err != nil {
+		c.logger.Error("failed to send reply", zap.Error(err))
+	}
+}
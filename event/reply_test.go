@@ -0,0 +1,184 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// testReplyEvent 记录回复内容的私聊消息事件
+type testReplyEvent struct {
+	*PrivateMessageEvent
+	replies []*message.Chain
+	quotes  []bool
+	// origin withReplyContext 返回的副本将回复记录到原事件中
+	origin *testReplyEvent
+}
+
+func newTestReplyEvent(raw string) *testReplyEvent {
+	return &testReplyEvent{PrivateMessageEvent: newTestPrivateMessageEvent(raw)}
+}
+
+func (e *testReplyEvent) Reply(msg *message.Chain, quote bool) (qq.MessageId, error) {
+	return e.ReplyContext(e.replyContext(), msg, quote)
+}
+
+func (e *testReplyEvent) ReplyContext(ctx context.Context, msg *message.Chain, quote bool) (qq.MessageId, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	origin := e
+	if e.origin != nil {
+		origin = e.origin
+	}
+	origin.replies = append(origin.replies, msg)
+	origin.quotes = append(origin.quotes, quote)
+	return 0, nil
+}
+
+func (e *testReplyEvent) withReplyContext(ctx context.Context) IMessageEvent {
+	origin := e
+	if e.origin != nil {
+		origin = e.origin
+	}
+	return &testReplyEvent{PrivateMessageEvent: e.PrivateMessageEvent.withReplyContext(ctx).(*PrivateMessageEvent), origin: origin}
+}
+
+type testResultCommand struct {
+	testCommand
+	execute func(parseResult *ParseResult) any
+}
+
+func (c *testResultCommand) Execute(parseResult *ParseResult) any {
+	return c.execute(parseResult)
+}
+
+type testSelfHandledCommand struct {
+	testCommand
+}
+
+func (c *testSelfHandledCommand) HandlesParseError() bool {
+	return true
+}
+
+func TestParseErrorReply(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	called := false
+	c.RegisterCommand(&testCommand{name: "music", mode: CmdNameModeNormal, getNew: func() any { return &testMusicArgs{} }, onCommand: func(*ParseResult) { called = true }})
+
+	// 默认不处理参数解析错误
+	e := newTestReplyEvent("music")
+	c.onMessageRecv(e)
+	assert.True(called)
+	assert.Empty(e.replies)
+
+	called = false
+	policy := DefaultReplyPolicy()
+	policy.ParseError = true
+	c.SetReplyPolicy(policy)
+	e = newTestReplyEvent("music")
+	c.onMessageRecv(e)
+	assert.False(called)
+	if assert.Len(e.replies, 1) {
+		text := e.replies[0].Messages[0].Data.(*message.TextData).Text
+		assert.True(strings.HasPrefix(text, "参数错误："))
+		assert.Contains(text, "用法：music <song-name>")
+		assert.True(e.quotes[0])
+	}
+
+	e = newTestReplyEvent("music --help")
+	c.onMessageRecv(e)
+	assert.False(called)
+	if assert.Len(e.replies, 1) {
+		text := e.replies[0].Messages[0].Data.(*message.TextData).Text
+		assert.True(strings.HasPrefix(text, "用法：music <song-name>"))
+	}
+
+	e = newTestReplyEvent("music song")
+	c.onMessageRecv(e)
+	assert.True(called)
+	assert.Empty(e.replies)
+}
+
+func TestParseErrorHandledByCommand(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	policy := DefaultReplyPolicy()
+	policy.ParseError = true
+	c.SetReplyPolicy(policy)
+	var got *ParseResult
+	c.RegisterCommand(&testSelfHandledCommand{testCommand{name: "music", mode: CmdNameModeNormal, getNew: func() any { return &testMusicArgs{} }, onCommand: func(p *ParseResult) { got = p }}})
+	e := newTestReplyEvent("music")
+	c.onMessageRecv(e)
+	if assert.NotNil(got) {
+		assert.Error(got.Error)
+	}
+	assert.Empty(e.replies)
+}
+
+func TestCommandResultReply(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	var result any
+	onCommandCalled := false
+	c.RegisterCommand(&testResultCommand{
+		testCommand: testCommand{name: "echo", mode: CmdNameModeNormal, getNew: func() any { return &testEchoArgs{} }, onCommand: func(*ParseResult) { onCommandCalled = true }},
+		execute:     func(*ParseResult) any { return result },
+	})
+
+	cases := []struct {
+		result any
+		text   string
+	}{
+		{"hello", "hello"},
+		{errors.New("failed"), "failed"},
+		{message.NewText("segment").Segment(), "segment"},
+		{message.NewText("chain").Segment().AsChain(), "chain"},
+	}
+	for _, tc := range cases {
+		result = tc.result
+		e := newTestReplyEvent("echo")
+		c.onMessageRecv(e)
+		if assert.Len(e.replies, 1) {
+			assert.Equal(tc.text, e.replies[0].Messages[0].Data.(*message.TextData).Text)
+			assert.True(e.quotes[0])
+		}
+	}
+	assert.False(onCommandCalled)
+
+	for _, r := range []any{nil, "", (*message.Chain)(nil), 42} {
+		result = r
+		e := newTestReplyEvent("echo")
+		c.onMessageRecv(e)
+		assert.Empty(e.replies)
+	}
+}
+
+func TestCommandResultForward(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	policy := DefaultReplyPolicy()
+	policy.ForwardThreshold = 5
+	c.SetReplyPolicy(policy)
+
+	e := newTestReplyEvent("")
+	chain, quote := c.resultChain(e, "一二三四五")
+	assert.True(quote)
+	assert.Equal(message.SegmentTypeText, chain.Messages[0].Type)
+
+	chain, quote = c.resultChain(e, "一二三四五六")
+	assert.False(quote)
+	assert.Equal(message.SegmentTypeNode, chain.Messages[0].Type)
+	assert.Equal("回复", chain.Messages[0].Data.(*message.CustomNodeData).Nickname)
+
+	c.Scopes().Set(PrivateScope(e.UserId), LocaleSettingKey, "en-US")
+	chain, _ = c.resultChain(e, "一二三四五六")
+	assert.Equal("Reply", chain.Messages[0].Data.(*message.CustomNodeData).Nickname)
+}
@@ -0,0 +1,278 @@
+package event
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/nekoite/go-napcat/utils"
+)
+
+// Scope 功能开关与设置的作用范围，为一个群（"group:<群号>"）或一个私聊（"user:<QQ 号>"）。
+type Scope string
+
+func GroupScope(groupId qq.GroupId) Scope {
+	return Scope("group:" + groupId.String())
+}
+
+func PrivateScope(userId qq.UserId) Scope {
+	return Scope("user:" + userId.String())
+}
+
+// ScopeOf 返回事件所在的范围。群中的事件属于群，其它与用户相关的事件属于与该用户的私聊。
+func ScopeOf(event IEvent) (Scope, bool) {
+	if e, ok := event.(IGroupEvent); ok {
+		return GroupScope(e.GetGroupId()), true
+	}
+	if e, ok := event.(IUserEvent); ok {
+		return PrivateScope(e.GetUserId()), true
+	}
+	return "", false
+}
+
+// ScopeSettings 一个范围的设置
+type ScopeSettings struct {
+	// Features 功能开关，键为指令名称或处理器名称。没有设置的功能使用默认值
+	Features map[string]bool `json:"features,omitempty"`
+	// Prefixes 该范围的全局激活前缀，为 nil 时使用全局设置，为空时该范围不需要前缀
+	Prefixes []string `json:"prefixes"`
+	// Values 自定义设置
+	Values map[string]string `json:"values,omitempty"`
+}
+
+func (s *ScopeSettings) clone() *ScopeSettings {
+	return &ScopeSettings{
+		Features: maps.Clone(s.Features),
+		Prefixes: slices.Clone(s.Prefixes),
+		Values:   maps.Clone(s.Values),
+	}
+}
+
+// ScopeStore 范围设置的持久化存储。
+type ScopeStore interface {
+	// Load 读取所有范围的设置
+	Load() (map[Scope]*ScopeSettings, error)
+	// Save 保存一个范围的设置。每次修改设置后都会被调用
+	Save(scope Scope, settings *ScopeSettings) error
+}
+
+// MemoryScopeStore 不做持久化的存储。
+type MemoryScopeStore struct{}
+
+func NewMemoryScopeStore() *MemoryScopeStore {
+	return &MemoryScopeStore{}
+}
+
+func (s *MemoryScopeStore) Load() (map[Scope]*ScopeSettings, error) {
+	return make(map[Scope]*ScopeSettings), nil
+}
+
+func (s *MemoryScopeStore) Save(scope Scope, settings *ScopeSettings) error {
+	return nil
+}
+
+// FileScopeStore 将所有范围的设置以 JSON 格式保存在一个文件中的存储。
+type FileScopeStore struct {
+	path     string
+	mu       sync.Mutex
+	settings map[Scope]json.RawMessage
+}
+
+// NewFileScopeStore 创建保存在 path 的文件存储。文件不存在时将在第一次保存时创建。
+func NewFileScopeStore(path string) *FileScopeStore {
+	return &FileScopeStore{
+		path:     path,
+		settings: make(map[Scope]json.RawMessage),
+	}
+}
+
+func (s *FileScopeStore) Load() (map[Scope]*ScopeSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[Scope]*ScopeSettings), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[Scope]json.RawMessage)
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	res := make(map[Scope]*ScopeSettings, len(raw))
+	for scope, r := range raw {
+		settings := new(ScopeSettings)
+		if err := json.Unmarshal(r, settings); err != nil {
+			return nil, err
+		}
+		res[scope] = settings
+	}
+	s.settings = raw
+	return res, nil
+}
+
+func (s *FileScopeStore) Save(scope Scope, settings *ScopeSettings) error {
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[scope] = raw
+	b, err := json.Marshal(s.settings)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.path, b)
+}
+
+// ScopeManager 管理每个群和私聊中功能的开关与设置。
+type ScopeManager struct {
+	mu       sync.RWMutex
+	store    ScopeStore
+	settings map[Scope]*ScopeSettings
+	defaults map[string]bool
+}
+
+// NewScopeManager 创建范围管理器，并从 store 中读取保存的设置。store 为 nil 时设置只保存在内存中。
+func NewScopeManager(store ScopeStore) (*ScopeManager, error) {
+	if store == nil {
+		store = NewMemoryScopeStore()
+	}
+	settings, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &ScopeManager{
+		store:    store,
+		settings: settings,
+		defaults: make(map[string]bool),
+	}, nil
+}
+
+func newMemoryScopeManager() *ScopeManager {
+	m, _ := NewScopeManager(nil)
+	return m
+}
+
+// SetDefaultEnabled 设置功能在没有单独设置的范围中是否启用。功能默认启用。
+func (m *ScopeManager) SetDefaultEnabled(feature string, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults[feature] = enabled
+}
+
+// IsEnabled 判断功能在范围中是否启用。
+func (m *ScopeManager) IsEnabled(scope Scope, feature string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.settings[scope]; ok {
+		if enabled, ok := s.Features[feature]; ok {
+			return enabled
+		}
+	}
+	if enabled, ok := m.defaults[feature]; ok {
+		return enabled
+	}
+	return true
+}
+
+// IsEnabledFor 判断功能在事件所在的范围中是否启用。不属于任何范围的事件使用默认值。
+func (m *ScopeManager) IsEnabledFor(event IEvent, feature string) bool {
+	scope, _ := ScopeOf(event)
+	return m.IsEnabled(scope, feature)
+}
+
+// SetEnabled 设置功能在范围中是否启用。
+func (m *ScopeManager) SetEnabled(scope Scope, feature string, enabled bool) error {
+	return m.SetFeaturesEnabled(scope, []string{feature}, enabled)
+}
+
+// SetFeaturesEnabled 同时设置多个功能在范围中是否启用，只保存一次。保存失败时所有功能都不修改。
+func (m *ScopeManager) SetFeaturesEnabled(scope Scope, features []string, enabled bool) error {
+	return m.update(scope, func(s *ScopeSettings) {
+		if s.Features == nil {
+			s.Features = make(map[string]bool)
+		}
+		for _, feature := range features {
+			s.Features[feature] = enabled
+		}
+	})
+}
+
+// Prefixes 返回范围的全局激活前缀。没有单独设置时返回 false。
+func (m *ScopeManager) Prefixes(scope Scope) ([]string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.settings[scope]; ok && s.Prefixes != nil {
+		return slices.Clone(s.Prefixes), true
+	}
+	return nil, false
+}
+
+// SetPrefixes 设置范围的全局激活前缀，不提供前缀表示该范围不需要前缀。
+func (m *ScopeManager) SetPrefixes(scope Scope, prefixes ...string) error {
+	return m.update(scope, func(s *ScopeSettings) {
+		s.Prefixes = append([]string{}, prefixes...)
+	})
+}
+
+// ResetPrefixes 清除范围的全局激活前缀，恢复使用全局设置。
+func (m *ScopeManager) ResetPrefixes(scope Scope) error {
+	return m.update(scope, func(s *ScopeSettings) {
+		s.Prefixes = nil
+	})
+}
+
+// Get 读取范围的自定义设置。
+func (m *ScopeManager) Get(scope Scope, key string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.settings[scope]; ok {
+		v, ok := s.Values[key]
+		return v, ok
+	}
+	return "", false
+}
+
+// Set 保存范围的自定义设置。
+func (m *ScopeManager) Set(scope Scope, key string, value string) error {
+	return m.update(scope, func(s *ScopeSettings) {
+		if s.Values == nil {
+			s.Values = make(map[string]string)
+		}
+		s.Values[key] = value
+	})
+}
+
+// Settings 返回范围的设置的副本。
+func (m *ScopeManager) Settings(scope Scope) ScopeSettings {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.settings[scope]; ok {
+		return *s.clone()
+	}
+	return ScopeSettings{}
+}
+
+// update 修改范围的设置并保存。保存失败时不修改。
+func (m *ScopeManager) update(scope Scope, f func(s *ScopeSettings)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.settings[scope]
+	if ok {
+		s = s.clone()
+	} else {
+		s = &ScopeSettings{}
+	}
+	f(s)
+	if err := m.store.Save(scope, s); err != nil {
+		return err
+	}
+	m.settings[scope] = s
+	return nil
+}
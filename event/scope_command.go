@@ -0,0 +1,210 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/nekoite/go-napcat/i18n"
+	"github.com/nekoite/go-napcat/message"
+	"go.uber.org/zap"
+)
+
+type scopeCommandArgs struct {
+	Enable  scopeEnableCmd  `cmd:"" aliases:"启用" help:"在当前聊天中启用指令或功能" i18n:"scope.help.enable"`
+	Disable scopeDisableCmd `cmd:"" aliases:"禁用" help:"在当前聊天中禁用指令或功能" i18n:"scope.help.disable"`
+	Prefix  scopePrefixCmd  `cmd:"" aliases:"前缀" help:"查看或设置当前聊天的指令激活前缀" i18n:"scope.help.prefix"`
+	Set     scopeSetCmd     `cmd:"" aliases:"设定" help:"修改当前聊天的自定义设置" i18n:"scope.help.set"`
+	Status  scopeStatusCmd  `cmd:"" aliases:"状态" help:"查看当前聊天的设置" i18n:"scope.help.status"`
+}
+
+type scopeEnableCmd struct {
+	Features []string `arg:"" help:"指令或功能名称" i18n:"scope.help.features"`
+}
+
+type scopeDisableCmd struct {
+	Features []string `arg:"" help:"指令或功能名称" i18n:"scope.help.features"`
+}
+
+type scopePrefixCmd struct {
+	Prefixes []string `arg:"" optional:"" help:"新的前缀，可以有多个" i18n:"scope.help.prefixes"`
+	Reset    bool     `short:"r" help:"恢复使用全局前缀" i18n:"scope.help.reset"`
+	None     bool     `short:"n" help:"当前聊天不需要前缀" i18n:"scope.help.none"`
+}
+
+type scopeSetCmd struct {
+	Key   string `arg:"" help:"设置名称" i18n:"scope.help.key"`
+	Value string `arg:"" help:"设置内容" i18n:"scope.help.value"`
+}
+
+type scopeStatusCmd struct{}
+
+// scopeCommand 管理范围设置的内置指令。它总是启用，不能被禁用。
+type scopeCommand struct {
+	*CommandGroup
+}
+
+func (c *scopeCommand) alwaysEnabled() {}
+
+// NewScopeCommand 创建管理当前聊天的指令开关与设置的内置指令组（名称 scope，别名 设置）。
+// 群中需要群管理员权限，私聊中只有超级用户可以使用。
+func NewScopeCommand(center *CommandCenter) ICommand {
+	return &scopeCommand{
+		CommandGroup: &CommandGroup{
+			Name:        "scope",
+			Aliases:     []string{"设置"},
+			Description: "scope.description",
+			Permissions: []Permission{PermissionGroupAdmin},
+			New:         func() any { return &scopeCommandArgs{} },
+			Options:     []kong.Option{kong.Bind(center)},
+			OnError: func(parseResult *ParseResult) {
+				replyText(center, parseResult.Event, parseResult.Error.Error())
+			},
+		},
+	}
+}
+
+func (cmd *scopeEnableCmd) Run(parseResult *ParseResult, center *CommandCenter) error {
+	return setFeatures(parseResult, center, cmd.Features, true)
+}
+
+func (cmd *scopeDisableCmd) Run(parseResult *ParseResult, center *CommandCenter) error {
+	return setFeatures(parseResult, center, cmd.Features, false)
+}
+
+func setFeatures(parseResult *ParseResult, center *CommandCenter, features []string, enabled bool) error {
+	scope, _ := ScopeOf(parseResult.Event)
+	names := make([]string, 0, len(features))
+	for _, feature := range features {
+		name, ok := resolveFeature(center, feature)
+		if !ok {
+			return errors.New(center.Translate(parseResult.Event, "scope.cannot_toggle", i18n.Args{"feature": feature}))
+		}
+		names = append(names, name)
+	}
+	// 一次写入所有修改，保存失败时不会只修改其中一部分
+	if err := center.scopes.SetFeaturesEnabled(scope, names, enabled); err != nil {
+		return err
+	}
+	key := "scope.disabled"
+	if enabled {
+		key = "scope.enabled"
+	}
+	separator := center.Translate(parseResult.Event, "list.separator", nil)
+	replyText(center, parseResult.Event, center.Translate(parseResult.Event, key, i18n.Args{"features": strings.Join(names, separator)}))
+	return nil
+}
+
+// resolveFeature 将指令的别名转换为指令名称。总是启用的指令不能修改开关。
+func resolveFeature(center *CommandCenter, feature string) (string, bool) {
+	cmd, ok := center.Commands[feature]
+	if !ok {
+		for _, other := range slices.Concat(center.PrefixCommands, center.RegexCommands, center.KeywordCommands) {
+			if slices.Contains(commandNames(other), feature) {
+				cmd = other
+				break
+			}
+		}
+	}
+	if cmd == nil {
+		return feature, true
+	}
+	if _, ok := cmd.(interface{ alwaysEnabled() }); ok {
+		return "", false
+	}
+	name, _ := cmd.GetName()
+	return name, true
+}
+
+func (cmd *scopePrefixCmd) Run(parseResult *ParseResult, center *CommandCenter) error {
+	scope, _ := ScopeOf(parseResult.Event)
+	var err error
+	switch {
+	case cmd.Reset:
+		err = center.scopes.ResetPrefixes(scope)
+	case cmd.None:
+		err = center.scopes.SetPrefixes(scope)
+	case len(cmd.Prefixes) > 0:
+		prefixes := make([]string, len(cmd.Prefixes))
+		for i, p := range cmd.Prefixes {
+			prefixes[i] = message.UnescapeCQString(p)
+		}
+		err = center.scopes.SetPrefixes(scope, prefixes...)
+	}
+	if err != nil {
+		return err
+	}
+	prefixes := formatPrefixes(center.catalog, parseResult.Locale, center.prefixesFor(parseResult.Event))
+	replyText(center, parseResult.Event, center.Translate(parseResult.Event, "scope.prefix", i18n.Args{"prefixes": prefixes}))
+	return nil
+}
+
+// formatPrefixes 以空格分隔前缀，不需要前缀时显示 无（按 locale 翻译）。
+func formatPrefixes(catalog *i18n.Catalog, locale string, prefixes []string) string {
+	if len(prefixes) == 0 || slices.Contains(prefixes, "") {
+		if len(prefixes) <= 1 {
+			return catalog.Translate(locale, "scope.prefix_none", nil)
+		}
+		nonEmpty := strings.Join(slices.DeleteFunc(slices.Clone(prefixes), func(p string) bool { return p == "" }), " ")
+		return catalog.Translate(locale, "scope.prefix_optional", i18n.Args{"prefixes": nonEmpty})
+	}
+	return strings.Join(prefixes, " ")
+}
+
+func (cmd *scopeSetCmd) Run(parseResult *ParseResult, center *CommandCenter) error {
+	scope, _ := ScopeOf(parseResult.Event)
+	if err := center.scopes.Set(scope, cmd.Key, message.UnescapeCQString(cmd.Value)); err != nil {
+		return err
+	}
+	replyText(center, parseResult.Event, center.Translate(parseResult.Event, "scope.set", i18n.Args{"key": cmd.Key}))
+	return nil
+}
+
+func (cmd *scopeStatusCmd) Run(parseResult *ParseResult, center *CommandCenter) error {
+	replyText(center, parseResult.Event, renderScopeStatus(center, parseResult.Event))
+	return nil
+}
+
+// renderScopeStatus 生成当前聊天的设置概览。
+func renderScopeStatus(center *CommandCenter, event IMessageEvent) string {
+	scope, _ := ScopeOf(event)
+	settings := center.scopes.Settings(scope)
+	locale := center.Locale(event)
+	t := func(key string, args i18n.Args) string {
+		return center.catalog.Translate(locale, key, args)
+	}
+	lines := []string{t("scope.scope", i18n.Args{"scope": scope})}
+	lines = append(lines, t("scope.prefix", i18n.Args{"prefixes": formatPrefixes(center.catalog, locale, center.prefixesFor(event))}))
+	disabled := make([]string, 0)
+	enabled := make([]string, 0)
+	for feature, on := range settings.Features {
+		if on {
+			enabled = append(enabled, feature)
+		} else {
+			disabled = append(disabled, feature)
+		}
+	}
+	slices.Sort(disabled)
+	slices.Sort(enabled)
+	separator := t("list.separator", nil)
+	if len(enabled) > 0 {
+		lines = append(lines, t("scope.enabled", i18n.Args{"features": strings.Join(enabled, separator)}))
+	}
+	if len(disabled) > 0 {
+		lines = append(lines, t("scope.disabled", i18n.Args{"features": strings.Join(disabled, separator)}))
+	}
+	keys := slices.Sorted(maps.Keys(settings.Values))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s = %s", k, settings.Values[k]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func replyText(center *CommandCenter, event IMessageEvent, text string) {
+	if _, err := event.Reply(message.NewText(text).Segment().AsChain(), true); err != nil {
+		center.logger.Error("failed to send reply", zap.Error(err))
+	}
+}
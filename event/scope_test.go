@@ -0,0 +1,171 @@
+package event
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestScopeOf(t *testing.T) {
+	assert := assert.New(t)
+	scope, ok := ScopeOf(newTestGroupMessageEvent("", 10, 1))
+	assert.True(ok)
+	assert.Equal(Scope("group:10"), scope)
+	e := newTestPrivateMessageEvent("")
+	e.UserId = 2
+	scope, ok = ScopeOf(e)
+	assert.True(ok)
+	assert.Equal(Scope("user:2"), scope)
+	_, ok = ScopeOf(&MetaEvent{})
+	assert.False(ok)
+}
+
+func TestScopeManager(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "scopes.json")
+	m, err := NewScopeManager(NewFileScopeStore(path))
+	assert.NoError(err)
+	g := GroupScope(10)
+
+	assert.True(m.IsEnabled(g, "music"))
+	assert.NoError(m.SetEnabled(g, "music", false))
+	assert.False(m.IsEnabled(g, "music"))
+	assert.True(m.IsEnabled(GroupScope(11), "music"))
+
+	m.SetDefaultEnabled("nsfw", false)
+	assert.False(m.IsEnabled(g, "nsfw"))
+	assert.NoError(m.SetEnabled(g, "nsfw", true))
+	assert.True(m.IsEnabled(g, "nsfw"))
+
+	_, ok := m.Prefixes(g)
+	assert.False(ok)
+	assert.NoError(m.SetPrefixes(g, "!", "！"))
+	assert.NoError(m.Set(g, "city", "上海"))
+
+	settings := m.Settings(g)
+	settings.Features["music"] = true
+	assert.False(m.IsEnabled(g, "music"))
+
+	m2, err := NewScopeManager(NewFileScopeStore(path))
+	assert.NoError(err)
+	assert.False(m2.IsEnabled(g, "music"))
+	assert.True(m2.IsEnabled(g, "nsfw"))
+	prefixes, ok := m2.Prefixes(g)
+	assert.True(ok)
+	assert.Equal([]string{"!", "！"}, prefixes)
+	v, ok := m2.Get(g, "city")
+	assert.True(ok)
+	assert.Equal("上海", v)
+
+	assert.NoError(m2.SetPrefixes(g))
+	prefixes, ok = m2.Prefixes(g)
+	assert.True(ok)
+	assert.Empty(prefixes)
+	m3, err := NewScopeManager(NewFileScopeStore(path))
+	assert.NoError(err)
+	prefixes, ok = m3.Prefixes(g)
+	assert.True(ok)
+	assert.Empty(prefixes)
+	assert.NoError(m3.ResetPrefixes(g))
+	_, ok = m3.Prefixes(g)
+	assert.False(ok)
+}
+
+func TestScopedCommandsAndHandlers(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetGlobalCommandPrefix("/")
+	commands := 0
+	handlers := 0
+	d.RegisterCommand(&testAliasCommand{
+		testCommand: testCommand{
+			name:      "ping",
+			mode:      CmdNameModeNormal,
+			getNew:    func() any { return &struct{}{} },
+			onCommand: func(parseResult *ParseResult) { commands++ },
+		},
+		aliases: []string{"乒"},
+	})
+	d.RegisterHandlerGroupMessage(d.Scoped("echo", func(event IEvent) { handlers++ }))
+	d.RegisterCommand(d.NewScopeCommand())
+
+	d.Dispatch(newTestGroupMessageEvent("/ping", 10, 1))
+	assert.Equal(1, commands)
+	assert.Equal(1, handlers)
+
+	admin := newTestGroupMessageEventWithRole("/scope disable 乒 echo", 10, 2, qq.GroupRoleAdmin)
+	d.Dispatch(admin)
+	assert.False(d.Scopes().IsEnabled(GroupScope(10), "ping"))
+	assert.False(d.Scopes().IsEnabled(GroupScope(10), "echo"))
+
+	d.Dispatch(newTestGroupMessageEvent("/ping", 10, 1))
+	d.Dispatch(newTestGroupMessageEvent("/ping", 11, 1))
+	assert.Equal(2, commands)
+	assert.Equal(2, handlers)
+
+	// 普通成员不能修改设置，scope 指令本身不能被禁用
+	d.Dispatch(newTestGroupMessageEventWithRole("/scope enable ping", 10, 1, qq.GroupRoleMember))
+	assert.False(d.Scopes().IsEnabled(GroupScope(10), "ping"))
+	d.Dispatch(newTestGroupMessageEventWithRole("/scope disable scope", 10, 2, qq.GroupRoleAdmin))
+	assert.True(d.Scopes().IsEnabled(GroupScope(10), "scope"))
+
+	d.Dispatch(newTestGroupMessageEventWithRole("/scope prefix !", 10, 2, qq.GroupRoleAdmin))
+	prefixes, _ := d.Scopes().Prefixes(GroupScope(10))
+	assert.Equal([]string{"!"}, prefixes)
+	d.Dispatch(newTestGroupMessageEventWithRole("!scope enable ping", 10, 2, qq.GroupRoleAdmin))
+	assert.True(d.Scopes().IsEnabled(GroupScope(10), "ping"))
+	d.Dispatch(newTestGroupMessageEvent("!ping", 10, 1))
+	assert.Equal(3, commands)
+}
+
+// testScopeStore 记录保存次数，fail 为 true 时保存失败
+type testScopeStore struct {
+	MemoryScopeStore
+	saves int
+	fail  bool
+}
+
+func (s *testScopeStore) Save(scope Scope, settings *ScopeSettings) error {
+	s.saves++
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func TestScopeCommandSavesOnce(t *testing.T) {
+	assert := assert.New(t)
+	store := &testScopeStore{fail: true}
+	m, err := NewScopeManager(store)
+	assert.NoError(err)
+	d := NewDispatcher(zap.NewNop(), false)
+	d.SetScopeManager(m)
+	d.SetGlobalCommandPrefix("/")
+	d.RegisterCommand(d.NewScopeCommand())
+
+	// 保存失败时所有功能都不修改
+	d.Dispatch(newTestGroupMessageEventWithRole("/scope disable music echo", 10, 2, qq.GroupRoleAdmin))
+	assert.Equal(1, store.saves)
+	assert.True(m.IsEnabled(GroupScope(10), "music"))
+	assert.True(m.IsEnabled(GroupScope(10), "echo"))
+
+	store.fail = false
+	d.Dispatch(newTestGroupMessageEventWithRole("/scope disable music echo", 10, 2, qq.GroupRoleAdmin))
+	assert.Equal(2, store.saves)
+	assert.False(m.IsEnabled(GroupScope(10), "music"))
+	assert.False(m.IsEnabled(GroupScope(10), "echo"))
+}
+
+func TestRenderScopeStatus(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCommandCenter(zap.NewNop())
+	c.SetGlobalCommandPrefix("/")
+	g := GroupScope(10)
+	assert.NoError(c.Scopes().SetEnabled(g, "music", false))
+	assert.NoError(c.Scopes().Set(g, "city", "上海"))
+	assert.Equal("范围：group:10\n指令前缀：/\n已禁用：music\ncity = 上海", renderScopeStatus(c, newTestGroupMessageEvent("", 10, 1)))
+}
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/nekoite/go-napcat/message"
+)
+
+// cqCodePattern 转义后的消息中的 CQ 码。文本中的 [ 与 ] 已被转义，所以不会被匹配
+var cqCodePattern = regexp.MustCompile(`\[CQ:[^\]]*\]`)
+
+// triggerMatch 正则或关键词指令的匹配结果
+type triggerMatch struct {
+	// name 匹配到的文本
+	name     string
+	captures map[string]string
+}
+
+// registerRegexCommand 编译正则指令的名称与所有别名。任意一个正则表达式无效时返回错误，不注册该指令。
+func (c *CommandCenter) registerRegexCommand(command ICommand) error {
+	compiled := make(map[string]*regexp.Regexp)
+	for _, pattern := range commandNames(command) {
+		if _, ok := c.regexps[pattern]; ok {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		compiled[pattern] = re
+	}
+	maps.Copy(c.regexps, compiled)
+	c.RegexCommands = append(c.RegexCommands, command)
+	return nil
+}
+
+// getTriggerCommand 在消息的任意位置查找正则指令和关键词指令。正则指令优先于关键词指令，同类指令按注册顺序匹配。
+// 只匹配消息中的文本：图片、@、回复等 CQ 码被去掉，剩余的文本片段拼接后再反转义。
+func (c *CommandCenter) getTriggerCommand(raw string) (ICommand, *triggerMatch) {
+	if len(c.RegexCommands) == 0 && len(c.KeywordCommands) == 0 {
+		return nil, nil
+	}
+	text := message.UnescapeCQString(cqCodePattern.ReplaceAllString(raw, ""))
+	for _, cmd := range c.RegexCommands {
+		for _, pattern := range commandNames(cmd) {
+			re := c.regexps[pattern]
+			m := re.FindStringSubmatch(text)
+			if m == nil {
+				continue
+			}
+			captures := make(map[string]string)
+			for i, name := range re.SubexpNames() {
+				if name != "" {
+					captures[name] = m[i]
+				}
+			}
+			return cmd, &triggerMatch{name: m[0], captures: captures}
+		}
+	}
+	for _, cmd := range c.KeywordCommands {
+		for _, keyword := range commandNames(cmd) {
+			if keyword != "" && strings.Contains(text, keyword) {
+				return cmd, &triggerMatch{name: keyword}
+			}
+		}
+	}
+	return nil, nil
+}
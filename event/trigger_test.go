@@ -0,0 +1,63 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestTriggerCommands(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	var got []string
+	var captures map[string]string
+	record := func(tag string) func(*ParseResult) {
+		return func(parseResult *ParseResult) {
+			got = append(got, tag+":"+parseResult.Name)
+			captures = parseResult.Captures
+		}
+	}
+	newArgs := func() any { return &struct{}{} }
+	d.RegisterCommand(&testCommand{name: "吃什么", mode: CmdNameModeKeyword, getNew: newArgs, onCommand: record("keyword")})
+	d.RegisterCommand(&testCommand{name: `(?P<who>\S+)今天吃什么`, mode: CmdNameModeRegex, getNew: newArgs, onCommand: record("regex")})
+	d.RegisterCommand(&testCommand{name: "今天", mode: CmdNameModePrefix, getNew: newArgs, onCommand: record("prefix")})
+	assert.Error(d.RegisterCommand(&testCommand{name: "[invalid", mode: CmdNameModeRegex, getNew: newArgs, onCommand: record("invalid")}))
+
+	d.Dispatch(newTestPrivateMessageEvent("请问 小明今天吃什么？"))
+	assert.Equal([]string{"regex:小明今天吃什么"}, got)
+	assert.Equal(map[string]string{"who": "小明"}, captures)
+
+	got = nil
+	d.Dispatch(newTestPrivateMessageEvent("晚上吃什么"))
+	assert.Equal([]string{"keyword:吃什么"}, got)
+	assert.Nil(captures)
+
+	got = nil
+	d.Dispatch(newTestPrivateMessageEvent("今天吃什么"))
+	assert.Equal([]string{"prefix:今天"}, got)
+
+	got = nil
+	d.Dispatch(newTestPrivateMessageEvent("&#91;invalid"))
+	assert.Nil(got)
+	assert.Len(d.commandCenter.RegexCommands, 1)
+}
+
+func TestTriggerCommandsIgnoreCQCodes(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	var got []string
+	record := func(parseResult *ParseResult) { got = append(got, parseResult.Name) }
+	d.RegisterCommand(&testCommand{name: "image", mode: CmdNameModeKeyword, onCommand: record})
+	d.RegisterCommand(&testCommand{name: `\d+`, mode: CmdNameModeRegex, onCommand: record})
+
+	d.Dispatch(newTestPrivateMessageEvent("[CQ:image,file=a.png]看看"))
+	d.Dispatch(newTestPrivateMessageEvent("[CQ:at,qq=123] 你好"))
+	d.Dispatch(newTestPrivateMessageEvent("[CQ:reply,id=5]好的"))
+	assert.Nil(got)
+
+	// 被 CQ 码隔开的文本片段拼接后匹配
+	d.Dispatch(newTestPrivateMessageEvent("ima[CQ:face,id=1]ge"))
+	d.Dispatch(newTestPrivateMessageEvent("[CQ:at,qq=123] 第42号 &#91;CQ:x&#93;"))
+	assert.Equal([]string{"image", "42"}, got)
+}
@@ -0,0 +1,167 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
+)
+
+// MessageFilter 用于筛选消息事件。它会在接收事件的 Go 程中同步调用，应当尽快返回。
+type MessageFilter func(event IMessageEvent) bool
+
+type waiter struct {
+	filter MessageFilter
+	ch     chan IMessageEvent
+}
+
+type waiterList struct {
+	mu      sync.Mutex
+	waiters []*waiter
+}
+
+func (l *waiterList) add(w *waiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiters = append(l.waiters, w)
+}
+
+// remove 移除等待者，返回它是否仍在等待（即尚未收到消息）。
+func (l *waiterList) remove(w *waiter) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, x := range l.waiters {
+		if x == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// deliver 将事件交给第一个匹配的等待者，返回事件是否被消费。
+func (l *waiterList) deliver(event IMessageEvent) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, w := range l.waiters {
+		if w.filter(event) {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			w.ch <- event
+			return true
+		}
+	}
+	return false
+}
+
+// WaitFor 阻塞直到下一条满足 filter 的消息到达，或 ctx 被取消。
+// 被等待到的消息将被消费，不会再触发指令和其它处理器。多个等待者同时匹配时，先开始等待的优先。
+func (d *Dispatcher) WaitFor(ctx context.Context, filter MessageFilter) (IMessageEvent, error) {
+	w := &waiter{
+		filter: filter,
+		ch:     make(chan IMessageEvent, 1),
+	}
+	d.waiters.add(w)
+	select {
+	case e := <-w.ch:
+		return e, nil
+	case <-ctx.Done():
+		if !d.waiters.remove(w) {
+			// 已经在取消的同时收到了消息，此时消息已被消费，不能丢弃
+			return <-w.ch, nil
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// NewSession 以 event 为起点创建一个会话，用于等待同一用户在同一聊天中的后续消息。
+func (d *Dispatcher) NewSession(event IMessageEvent) *Session {
+	return &Session{
+		dispatcher: d,
+		Origin:     event,
+		Last:       event,
+	}
+}
+
+// Session 多轮对话会话。
+type Session struct {
+	dispatcher *Dispatcher
+	// Origin 开启会话的消息
+	Origin IMessageEvent
+	// Last 会话中最近的一条消息
+	Last IMessageEvent
+}
+
+// Next 等待同一用户在同一聊天中的下一条消息。
+func (s *Session) Next(ctx context.Context) (IMessageEvent, error) {
+	return s.NextMatch(ctx, nil)
+}
+
+// NextMatch 等待同一用户在同一聊天中的下一条满足 filter 的消息。filter 为 nil 时匹配任意消息。
+func (s *Session) NextMatch(ctx context.Context, filter MessageFilter) (IMessageEvent, error) {
+	f := SameConversation(s.Origin)
+	if filter != nil {
+		f = AllOf(f, filter)
+	}
+	e, err := s.dispatcher.WaitFor(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	s.Last = e
+	return e, nil
+}
+
+// Prompt 回复最近的一条消息，然后等待用户的下一条消息。
+func (s *Session) Prompt(ctx context.Context, msg *message.Chain, quote bool) (IMessageEvent, error) {
+	if _, err := s.Last.Reply(msg, quote); err != nil {
+		return nil, err
+	}
+	return s.Next(ctx)
+}
+
+// SameConversation 匹配与 event 来自同一用户且位于同一聊天（同一个群或同为私聊）的消息。
+func SameConversation(event IMessageEvent) MessageFilter {
+	userId := event.GetUserId()
+	groupId, isGroup := getGroupId(event)
+	return func(e IMessageEvent) bool {
+		if e.GetUserId() != userId {
+			return false
+		}
+		gid, ok := getGroupId(e)
+		return ok == isGroup && gid == groupId
+	}
+}
+
+// FromUser 匹配来自 userId 的消息。
+func FromUser(userId qq.UserId) MessageFilter {
+	return func(e IMessageEvent) bool {
+		return e.GetUserId() == userId
+	}
+}
+
+// InGroup 匹配来自群 groupId 的消息。
+func InGroup(groupId qq.GroupId) MessageFilter {
+	return func(e IMessageEvent) bool {
+		gid, ok := getGroupId(e)
+		return ok && gid == groupId
+	}
+}
+
+// AllOf 匹配满足所有 filters 的消息。
+func AllOf(filters ...MessageFilter) MessageFilter {
+	return func(e IMessageEvent) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func getGroupId(event IEvent) (qq.GroupId, bool) {
+	if e, ok := event.(IGroupEvent); ok {
+		return e.GetGroupId(), true
+	}
+	return 0, false
+}
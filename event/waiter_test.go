@@ -0,0 +1,107 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestGroupMessageEvent(raw string, groupId int64, userId int64) *GroupMessageEvent {
+	e := &GroupMessageEvent{}
+	e.EventType = EventTypeMessage
+	e.MessageType = MessageEventTypeGroup
+	e.RawMessage = raw
+	e.GroupId = qq.GroupId(groupId)
+	e.UserId = qq.UserId(userId)
+	return e
+}
+
+func waitUntilWaiting(d *Dispatcher, n int) {
+	for {
+		d.waiters.mu.Lock()
+		l := len(d.waiters.waiters)
+		d.waiters.mu.Unlock()
+		if l >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaitForConsumesMessage(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	handled := false
+	d.RegisterHandlerAllTypes(func(event IEvent) {
+		handled = true
+	})
+	origin := newTestGroupMessageEvent("start", 1, 2)
+	result := make(chan IMessageEvent, 1)
+	go func() {
+		e, err := d.WaitFor(context.Background(), SameConversation(origin))
+		assert.Nil(err)
+		result <- e
+	}()
+	waitUntilWaiting(d, 1)
+
+	other := newTestGroupMessageEvent("other user", 1, 3)
+	d.Dispatch(other)
+	assert.True(handled)
+
+	handled = false
+	reply := newTestGroupMessageEvent("reply", 1, 2)
+	d.Dispatch(reply)
+	assert.False(handled)
+	assert.Equal(reply, <-result)
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	e, err := d.WaitFor(ctx, FromUser(1))
+	assert.Nil(e)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Empty(d.waiters.waiters)
+}
+
+func TestSessionNext(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(zap.NewNop(), false)
+	origin := newTestGroupMessageEvent("start", 1, 2)
+	s := d.NewSession(origin)
+	result := make(chan IMessageEvent, 1)
+	go func() {
+		e, err := s.Next(context.Background())
+		assert.Nil(err)
+		result <- e
+	}()
+	waitUntilWaiting(d, 1)
+	private := newTestPrivateMessageEvent("private")
+	private.UserId = 2
+	d.Dispatch(private)
+	otherGroup := newTestGroupMessageEvent("other group", 3, 2)
+	d.Dispatch(otherGroup)
+	reply := newTestGroupMessageEvent("reply", 1, 2)
+	d.Dispatch(reply)
+	assert.Equal(reply, <-result)
+	assert.Equal(reply, s.Last)
+	assert.Equal(origin, s.Origin)
+}
+
+func TestMessageFilters(t *testing.T) {
+	assert := assert.New(t)
+	e := newTestGroupMessageEvent("hello", 1, 2)
+	assert.True(FromUser(2)(e))
+	assert.False(FromUser(1)(e))
+	assert.True(InGroup(1)(e))
+	assert.False(InGroup(2)(e))
+	assert.True(AllOf(FromUser(2), InGroup(1))(e))
+	assert.False(AllOf(FromUser(2), InGroup(2))(e))
+	assert.False(InGroup(0)(newTestPrivateMessageEvent("hello")))
+}
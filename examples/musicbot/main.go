@@ -1,76 +1,104 @@
 package main
 
 import (
+	"context"
+	"embed"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
-	"github.com/alecthomas/kong"
 	gonapcat "github.com/nekoite/go-napcat"
 	"github.com/nekoite/go-napcat/config"
 	"github.com/nekoite/go-napcat/event"
 	"github.com/nekoite/go-napcat/extensions/napcat"
+	"github.com/nekoite/go-napcat/i18n"
 	"github.com/nekoite/go-napcat/message"
 	"github.com/tidwall/gjson"
 )
 
 type MusicCommandArgs struct {
-	Platform string `short:"p" enum:"163,qq" optional:"" default:"qq" help:"音乐平台，163 或 qq"`
-	SongName string `arg:"" required:"" help:"歌曲名"`
+	Platform string `short:"p" enum:"163,qq" optional:"" default:"qq" help:"音乐平台，163 或 qq" i18n:"music.args.platform"`
+	SongName string `arg:"" required:"" help:"歌曲名" i18n:"music.args.song_name"`
 }
 
 type MusicCommand struct {
+	event.CommandBase
 	bot *gonapcat.Bot
 }
 
+// 在编译时检查可选接口的签名
+var (
+	_ event.ICommandWithDescription = (*MusicCommand)(nil)
+	_ event.ICommandWithPreprocess  = (*MusicCommand)(nil)
+	_ event.ICommandWithRateLimit   = (*MusicCommand)(nil)
+	_ event.ICommandWithResult      = (*MusicCommand)(nil)
+	_ event.ICommandWithTimeout     = (*MusicCommand)(nil)
+)
+
 func (c *MusicCommand) GetName() (string, event.CmdNameMode) {
 	return "music", event.CmdNameModeNormal
 }
 
+// GetDescription 返回消息目录中的键，帮助指令会将它翻译为当前聊天的语言
+func (c *MusicCommand) GetDescription() string {
+	return "music.description"
+}
+
 func (c *MusicCommand) GetNew() any {
 	return &MusicCommandArgs{}
 }
 
-func (c *MusicCommand) GetOptions() []kong.Option {
-	return nil
+// Preprocess 将全角空格替换为半角空格，以便分割参数
+func (c *MusicCommand) Preprocess(remaining string) string {
+	return strings.ReplaceAll(remaining, "　", " ")
 }
 
-func (c *MusicCommand) SplitBySpaceOnly() bool {
-	return false
+func (c *MusicCommand) GetRateLimit() event.RateLimit {
+	return event.RateLimit{
+		UserCooldown:  30 * time.Second,
+		GroupCooldown: 5 * time.Second,
+	}
 }
 
-func (c *MusicCommand) Preprocess(remaining string) {}
-
-func (c *MusicCommand) OnCommand(parseResult *event.ParseResult) {
+// Execute 的返回值由指令中心自动回复，参数错误已经由指令中心处理
+func (c *MusicCommand) Execute(parseResult *event.ParseResult) any {
 	args := parseResult.ParsedArgs.(*MusicCommandArgs)
-	if parseResult.Error != nil {
-		if parseResult.StdOut != "" {
-			parseResult.Event.Reply(message.NewText(parseResult.StdOut).Segment().AsChain(), true)
-		} else {
-			parseResult.Event.Reply(message.NewText(parseResult.Error.Error()).Segment().AsChain(), true)
-		}
-		return
-	}
 	switch args.Platform {
 	case "qq":
-		id, err := getQQMusicId(args.SongName)
+		id, err := getQQMusicId(parseResult.Context, args.SongName)
+		if errors.Is(err, errNotFound) {
+			return c.bot.Catalog().Translate(parseResult.Locale, "music.not_found", nil)
+		}
 		if err != nil {
-			parseResult.Event.Reply(message.NewText(err.Error()).Segment().AsChain(), true)
-			return
+			return err
 		}
 		napcat.SetMsgEmojiLike(c.bot, parseResult.Event.GetMessageId(), 128166)
 		parseResult.Event.Reply(message.NewMusic(message.MusicTypeQQ, id).Segment().AsChain(), false)
+		return nil
 	default:
-		parseResult.Event.Reply(message.NewText("暂不支持").Segment().AsChain(), true)
+		return c.bot.Catalog().Translate(parseResult.Locale, "music.unsupported", nil)
 	}
 }
 
-func getQQMusicId(songName string) (int64, error) {
-	resp, err := http.Get(fmt.Sprintf("https://c6.y.qq.com/splcloud/fcgi-bin/smartbox_new.fcg?_=1724470252605&cv=4747474&ct=24&format=json&inCharset=utf-8&outCharset=utf-8&notice=0&platform=yqq.json&needNewCode=1&uin=0&g_tk_new_20200303=1198146162&g_tk=1198146162&hostUin=0&is_xml=0&key=%s", url.QueryEscape(songName)))
+// GetTimeout 查询歌曲超过 10 秒后放弃
+func (c *MusicCommand) GetTimeout() time.Duration {
+	return 10 * time.Second
+}
+
+var errNotFound = errors.New("song not found")
+
+func getQQMusicId(ctx context.Context, songName string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://c6.y.qq.com/splcloud/fcgi-bin/smartbox_new.fcg?_=1724470252605&cv=4747474&ct=24&format=json&inCharset=utf-8&outCharset=utf-8&notice=0&platform=yqq.json&needNewCode=1&uin=0&g_tk_new_20200303=1198146162&g_tk=1198146162&hostUin=0&is_xml=0&key=%s", url.QueryEscape(songName)), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return 0, err
 	}
@@ -81,19 +109,44 @@ func getQQMusicId(songName string) (int64, error) {
 	}
 	res := gjson.GetBytes(body, "data.song.itemlist.0.id").Int()
 	if res == 0 {
-		return 0, fmt.Errorf("未找到")
+		return 0, errNotFound
 	}
 	return res, nil
 }
 
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// loadLocales 将示例自己的消息加入消息目录，群管理员可以使用 `scope set locale en-US` 切换语言
+func loadLocales(catalog *i18n.Catalog) error {
+	for _, locale := range []string{"zh-CN", "en-US"} {
+		data, err := locales.ReadFile("locales/" + locale + ".yaml")
+		if err != nil {
+			return err
+		}
+		if err := catalog.Load(locale, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func main() {
 	napcat.Extension.Register()
 	gonapcat.Init(config.DefaultLogConfig().WithStderr().WithLevel("debug"))
-	bot, err := gonapcat.NewBot(config.DefaultBotConfig(1341400490, "114514"))
+	bot, err := gonapcat.NewBot(config.DefaultBotConfig(1341400490, "114514").WithRateLimitReply("command.cooldown", "command.quota").WithParseErrorReply(true, 300))
 	if err != nil {
 		panic(err)
 	}
-	bot.RegisterCommand(&MusicCommand{bot: bot})
+	if err := loadLocales(bot.Catalog()); err != nil {
+		panic(err)
+	}
+	if err := bot.RegisterCommand(&MusicCommand{bot: bot}); err != nil {
+		panic(err)
+	}
+	if err := bot.RegisterCommand(bot.NewHelpCommand()); err != nil {
+		panic(err)
+	}
 	bot.Start()
 	defer gonapcat.Finalize()
 
@@ -0,0 +1,388 @@
+// Package fsm 提供基于有限状态机的多轮对话会话。
+//
+// 会话以（群号，用户 QQ）为键，私聊时群号为 0。会话开启后，该用户在该聊天中的后续消息会被路由到会话当前步骤的处理函数，
+// 而不会触发指令与其它处理器。
+package fsm
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/nekoite/go-napcat/event"
+	"github.com/nekoite/go-napcat/message"
+	"github.com/nekoite/go-napcat/qq"
+	"go.uber.org/zap"
+)
+
+// Key 会话的键。私聊时 GroupId 为 0。
+type Key struct {
+	GroupId qq.GroupId `json:"group_id"`
+	UserId  qq.UserId  `json:"user_id"`
+}
+
+// State 会话的状态。它会被序列化后保存到 [Store] 中。
+//
+// 管理器中保存的状态的字段只在持有管理器的锁时被读写；步骤处理函数读写的是在锁中复制的副本，处理函数返回后才在锁中写回。
+type State struct {
+	Machine   string                     `json:"machine"`
+	Step      string                     `json:"step"`
+	Data      map[string]json.RawMessage `json:"data"`
+	ExpiresAt time.Time                  `json:"expires_at"`
+
+	// mu 保证同一会话的消息依次被处理
+	mu sync.Mutex `json:"-"`
+}
+
+// StepHandler 会话步骤的处理函数。
+// 处理函数中调用 [Context.Goto] 进入下一步骤，调用 [Context.Finish] 结束会话。两者都不调用时，会话停留在当前步骤。
+type StepHandler func(ctx *Context)
+
+// Machine 状态机定义。
+type Machine struct {
+	Name string
+	// Initial 会话开始时所在的步骤
+	Initial string
+	Steps   map[string]StepHandler
+	// Timeout 会话在没有新消息时的超时时间，每收到一条消息都会重新计时。为 0 时不会超时
+	Timeout time.Duration
+	// CancelKeywords 收到与其中任意一个相同的消息（去除首尾空白后）时取消会话
+	CancelKeywords []string
+	// OnCancel 会话被取消时调用，可为 nil
+	OnCancel func(ctx *Context)
+	// OnTimeout 会话超时被清理时调用，可为 nil
+	OnTimeout func(key Key, state *State)
+}
+
+// Manager 会话管理器。使用 [Manager.Intercept] 作为消息拦截器注册到机器人上。
+type Manager struct {
+	logger   *zap.Logger
+	store    Store
+	mu       sync.Mutex
+	machines map[string]*Machine
+	sessions map[Key]*State
+}
+
+// Context 步骤处理函数的上下文。
+type Context struct {
+	Event   event.IMessageEvent
+	Key     Key
+	State   *State
+	manager *Manager
+	machine *Machine
+	next    string
+	done    bool
+}
+
+// NewManager 创建会话管理器，并从 store 中恢复未完成的会话。store 为 nil 时会话只保存在内存中。
+func NewManager(logger *zap.Logger, store Store) (*Manager, error) {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	sessions, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = make(map[Key]*State)
+	}
+	return &Manager{
+		logger:   logger.Named("fsm"),
+		store:    store,
+		machines: make(map[string]*Machine),
+		sessions: sessions,
+	}, nil
+}
+
+// Register 注册状态机。恢复的会话在其状态机被注册之前不会被处理。
+func (m *Manager) Register(machine *Machine) error {
+	if _, ok := machine.Steps[machine.Initial]; !ok {
+		return errors.ErrUnknownStep
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.machines[machine.Name]; ok {
+		return errors.ErrMachineAlreadyRegistered
+	}
+	m.machines[machine.Name] = machine
+	return nil
+}
+
+// Start 为 e 的发送者开启一个会话，已有的会话将被替换。data 为会话的初始数据，可为 nil。
+// 开启会话的消息本身不会被路由到步骤处理函数，通常在开启会话后直接回复提示内容。
+func (m *Manager) Start(e event.IMessageEvent, machineName string, data map[string]any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	machine, ok := m.machines[machineName]
+	if !ok {
+		return errors.ErrUnknownMachine
+	}
+	state := &State{
+		Machine: machineName,
+		Step:    machine.Initial,
+		Data:    make(map[string]json.RawMessage, len(data)),
+	}
+	for k, v := range data {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		state.Data[k] = raw
+	}
+	state.touch(machine)
+	key := KeyOf(e)
+	m.sessions[key] = state
+	return m.store.Save(key, state)
+}
+
+// Cancel 取消 key 对应的会话，不会调用 OnCancel。返回会话是否存在。
+func (m *Manager) Cancel(key Key) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[key]; !ok {
+		return false
+	}
+	m.remove(key)
+	return true
+}
+
+// Get 返回 key 对应的会话状态的副本，不存在或已超时时返回 nil。
+func (m *Manager) Get(key Key) *State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.sessions[key]
+	if !ok || state.expired(time.Now()) {
+		return nil
+	}
+	return state.clone()
+}
+
+// Intercept 消息拦截器。如果消息的发送者在对应聊天中有进行中的会话，则将消息交给当前步骤处理并消费该消息。
+func (m *Manager) Intercept(e event.IMessageEvent) bool {
+	key := KeyOf(e)
+	m.mu.Lock()
+	state, ok := m.sessions[key]
+	if !ok {
+		m.mu.Unlock()
+		return false
+	}
+	machine, ok := m.machines[state.Machine]
+	if !ok {
+		m.mu.Unlock()
+		return false
+	}
+	if state.expired(time.Now()) {
+		m.remove(key)
+		m.mu.Unlock()
+		m.onTimeout(machine, key, state)
+		return false
+	}
+	m.mu.Unlock()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	m.mu.Lock()
+	if m.sessions[key] != state {
+		// 等待前一条消息处理完毕期间会话被替换或移除
+		m.mu.Unlock()
+		return m.Intercept(e)
+	}
+	work := state.clone()
+	m.mu.Unlock()
+	ctx := &Context{
+		Event:   e,
+		Key:     key,
+		State:   work,
+		manager: m,
+		machine: machine,
+	}
+	if machine.isCancelKeyword(e) {
+		m.finish(key, state)
+		if machine.OnCancel != nil {
+			machine.OnCancel(ctx)
+		}
+		return true
+	}
+	// 读取在管理器的锁中复制的 work，而不是 state
+	handler, ok := machine.Steps[work.Step]
+	if !ok {
+		m.logger.Error("unknown step, dropping session", zap.String("machine", machine.Name), zap.String("step", work.Step))
+		m.finish(key, state)
+		return true
+	}
+	handler(ctx)
+	if ctx.done {
+		m.finish(key, state)
+		return true
+	}
+	if ctx.next != "" {
+		work.Step = ctx.next
+	}
+	m.commit(key, state, work, machine)
+	return true
+}
+
+// Sweep 清理所有已超时的会话。
+func (m *Manager) Sweep() {
+	now := time.Now()
+	type expiredSession struct {
+		key     Key
+		state   *State
+		machine *Machine
+	}
+	var expired []expiredSession
+	m.mu.Lock()
+	for key, state := range m.sessions {
+		if state.expired(now) {
+			expired = append(expired, expiredSession{key, state, m.machines[state.Machine]})
+			m.remove(key)
+		}
+	}
+	m.mu.Unlock()
+	for _, s := range expired {
+		m.onTimeout(s.machine, s.key, s.state)
+	}
+}
+
+// RunSweeper 每隔 interval 清理一次超时的会话，直到 stop 被关闭。
+func (m *Manager) RunSweeper(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Sweep()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *Manager) onTimeout(machine *Machine, key Key, state *State) {
+	if machine != nil && machine.OnTimeout != nil {
+		machine.OnTimeout(key, state)
+	}
+}
+
+// finish 在不持有 m.mu 的情况下移除会话。
+func (m *Manager) finish(key Key, state *State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 处理期间会话可能已被新的会话替换
+	if m.sessions[key] == state {
+		m.remove(key)
+	}
+}
+
+// commit 将处理函数修改后的副本写回会话并保存。处理期间会话被替换或移除时丢弃修改。
+func (m *Manager) commit(key Key, state *State, work *State, machine *Machine) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[key] != state {
+		return
+	}
+	state.Step = work.Step
+	state.Data = work.Data
+	state.touch(machine)
+	if err := m.store.Save(key, state); err != nil {
+		m.logger.Error("failed to save session", zap.Error(err))
+	}
+}
+
+// remove 需要在持有 m.mu 时调用。
+func (m *Manager) remove(key Key) {
+	delete(m.sessions, key)
+	if err := m.store.Delete(key); err != nil {
+		m.logger.Error("failed to delete session", zap.Error(err))
+	}
+}
+
+// clone 需要在持有管理器的锁时调用。
+func (s *State) clone() *State {
+	data := maps.Clone(s.Data)
+	if data == nil {
+		data = make(map[string]json.RawMessage)
+	}
+	return &State{
+		Machine:   s.Machine,
+		Step:      s.Step,
+		Data:      data,
+		ExpiresAt: s.ExpiresAt,
+	}
+}
+
+// touch 需要在持有管理器的锁时调用。
+func (s *State) touch(machine *Machine) {
+	if machine.Timeout > 0 {
+		s.ExpiresAt = time.Now().Add(machine.Timeout)
+	} else {
+		s.ExpiresAt = time.Time{}
+	}
+}
+
+func (s *State) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt)
+}
+
+func (machine *Machine) isCancelKeyword(e event.IMessageEvent) bool {
+	if len(machine.CancelKeywords) == 0 {
+		return false
+	}
+	text := strings.TrimSpace(message.UnescapeCQString(e.GetRawMessage()))
+	for _, k := range machine.CancelKeywords {
+		if text == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Goto 在处理函数返回后进入 step 步骤。
+func (c *Context) Goto(step string) {
+	if _, ok := c.machine.Steps[step]; !ok {
+		c.manager.logger.Error("goto unknown step", zap.String("machine", c.machine.Name), zap.String("step", step))
+		return
+	}
+	c.next = step
+}
+
+// Finish 在处理函数返回后结束会话。
+func (c *Context) Finish() {
+	c.done = true
+}
+
+// Get 将会话数据中 key 对应的值解析到 v 中。key 不存在时返回 false。
+func (c *Context) Get(key string, v any) (bool, error) {
+	raw, ok := c.State.Data[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Set 设置会话数据。v 需要能被序列化为 JSON。
+func (c *Context) Set(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.State.Data[key] = raw
+	return nil
+}
+
+// Reply 回复当前消息。
+func (c *Context) Reply(msg *message.Chain, quote bool) (qq.MessageId, error) {
+	return c.Event.Reply(msg, quote)
+}
+
+// KeyOf 返回消息发送者所在会话的键。
+func KeyOf(e event.IMessageEvent) Key {
+	key := Key{UserId: e.GetUserId()}
+	if ge, ok := e.(event.IGroupEvent); ok {
+		key.GroupId = ge.GetGroupId()
+	}
+	return key
+}
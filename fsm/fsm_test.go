@@ -0,0 +1,172 @@
+package fsm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nekoite/go-napcat/event"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestEvent(raw string, groupId qq.GroupId, userId qq.UserId) *event.GroupMessageEvent {
+	e := &event.GroupMessageEvent{}
+	e.EventType = event.EventTypeMessage
+	e.MessageType = event.MessageEventTypeGroup
+	e.RawMessage = raw
+	e.GroupId = groupId
+	e.UserId = userId
+	return e
+}
+
+func newSignUpMachine(results *[]string) *Machine {
+	return &Machine{
+		Name:    "signup",
+		Initial: "name",
+		Steps: map[string]StepHandler{
+			"name": func(ctx *Context) {
+				ctx.Set("name", ctx.Event.GetRawMessage())
+				ctx.Goto("age")
+			},
+			"age": func(ctx *Context) {
+				var name string
+				ctx.Get("name", &name)
+				*results = append(*results, name+":"+ctx.Event.GetRawMessage())
+				ctx.Finish()
+			},
+		},
+		CancelKeywords: []string{"取消"},
+		OnCancel: func(ctx *Context) {
+			*results = append(*results, "cancelled")
+		},
+	}
+}
+
+func TestManagerSteps(t *testing.T) {
+	assert := assert.New(t)
+	var results []string
+	m, err := NewManager(zap.NewNop(), nil)
+	assert.Nil(err)
+	assert.Nil(m.Register(newSignUpMachine(&results)))
+	assert.ErrorContains(m.Register(newSignUpMachine(&results)), "already registered")
+
+	assert.False(m.Intercept(newTestEvent("alice", 1, 2)))
+	assert.Nil(m.Start(newTestEvent("signup", 1, 2), "signup", nil))
+	assert.False(m.Intercept(newTestEvent("other user", 1, 3)))
+	assert.False(m.Intercept(newTestEvent("other group", 4, 2)))
+	assert.True(m.Intercept(newTestEvent("alice", 1, 2)))
+	assert.Equal("age", m.Get(Key{GroupId: 1, UserId: 2}).Step)
+	assert.True(m.Intercept(newTestEvent("18", 1, 2)))
+	assert.Equal([]string{"alice:18"}, results)
+	assert.Nil(m.Get(Key{GroupId: 1, UserId: 2}))
+	assert.False(m.Intercept(newTestEvent("after", 1, 2)))
+}
+
+func TestManagerCancel(t *testing.T) {
+	assert := assert.New(t)
+	var results []string
+	m, _ := NewManager(zap.NewNop(), nil)
+	m.Register(newSignUpMachine(&results))
+	assert.ErrorContains(m.Start(newTestEvent("signup", 1, 2), "unknown", nil), "unknown state machine")
+	m.Start(newTestEvent("signup", 1, 2), "signup", nil)
+	assert.True(m.Intercept(newTestEvent(" 取消 ", 1, 2)))
+	assert.Equal([]string{"cancelled"}, results)
+	assert.Nil(m.Get(Key{GroupId: 1, UserId: 2}))
+}
+
+func TestManagerTimeout(t *testing.T) {
+	assert := assert.New(t)
+	var results []string
+	var timedOut []Key
+	m, _ := NewManager(zap.NewNop(), nil)
+	machine := newSignUpMachine(&results)
+	machine.Timeout = time.Millisecond
+	machine.OnTimeout = func(key Key, state *State) {
+		timedOut = append(timedOut, key)
+	}
+	m.Register(machine)
+	m.Start(newTestEvent("signup", 1, 2), "signup", nil)
+	m.Start(newTestEvent("signup", 0, 3), "signup", nil)
+	time.Sleep(5 * time.Millisecond)
+	assert.False(m.Intercept(newTestEvent("alice", 1, 2)))
+	m.Sweep()
+	assert.ElementsMatch([]Key{{GroupId: 1, UserId: 2}, {GroupId: 0, UserId: 3}}, timedOut)
+	assert.Empty(results)
+}
+
+func TestManagerPersistence(t *testing.T) {
+	assert := assert.New(t)
+	var results []string
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	m, err := NewManager(zap.NewNop(), NewFileStore(path))
+	assert.Nil(err)
+	m.Register(newSignUpMachine(&results))
+	m.Start(newTestEvent("signup", 1, 2), "signup", map[string]any{"source": "test"})
+	m.Intercept(newTestEvent("alice", 1, 2))
+
+	m2, err := NewManager(zap.NewNop(), NewFileStore(path))
+	assert.Nil(err)
+	m2.Register(newSignUpMachine(&results))
+	state := m2.Get(Key{GroupId: 1, UserId: 2})
+	assert.NotNil(state)
+	assert.Equal("age", state.Step)
+	assert.JSONEq(`"test"`, string(state.Data["source"]))
+	assert.True(m2.Intercept(newTestEvent("18", 1, 2)))
+	assert.Equal([]string{"alice:18"}, results)
+
+	m3, err := NewManager(zap.NewNop(), NewFileStore(path))
+	assert.Nil(err)
+	assert.Nil(m3.Get(Key{GroupId: 1, UserId: 2}))
+}
+
+func TestManagerSessionReplacedDuringHandler(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	m, _ := NewManager(zap.NewNop(), NewFileStore(path))
+	key := Key{GroupId: 1, UserId: 2}
+	m.Register(&Machine{Name: "other", Initial: "start", Steps: map[string]StepHandler{"start": func(ctx *Context) {}}})
+	m.Register(&Machine{
+		Name:    "restart",
+		Initial: "start",
+		Steps: map[string]StepHandler{
+			"start": func(ctx *Context) {
+				ctx.Set("stale", true)
+				m.Start(ctx.Event, "other", nil)
+			},
+			"cancel": func(ctx *Context) {
+				m.Cancel(ctx.Key)
+				ctx.Goto("start")
+			},
+		},
+	})
+
+	m.Start(newTestEvent("restart", 1, 2), "restart", nil)
+	assert.True(m.Intercept(newTestEvent("go", 1, 2)))
+	state := m.Get(key)
+	assert.Equal("other", state.Machine)
+	assert.NotContains(state.Data, "stale")
+	m2, _ := NewManager(zap.NewNop(), NewFileStore(path))
+	assert.Equal("other", m2.Get(key).Machine)
+
+	m.Start(newTestEvent("restart", 1, 2), "restart", nil)
+	m.sessions[key].Step = "cancel"
+	assert.True(m.Intercept(newTestEvent("go", 1, 2)))
+	assert.Nil(m.Get(key))
+	m3, _ := NewManager(zap.NewNop(), NewFileStore(path))
+	assert.Nil(m3.Get(key))
+}
+
+func TestManagerGetReturnsCopy(t *testing.T) {
+	assert := assert.New(t)
+	var results []string
+	m, _ := NewManager(zap.NewNop(), nil)
+	m.Register(newSignUpMachine(&results))
+	m.Start(newTestEvent("signup", 1, 2), "signup", nil)
+	state := m.Get(Key{GroupId: 1, UserId: 2})
+	state.Step = "age"
+	state.Data["name"] = []byte(`"bob"`)
+	assert.Equal("name", m.Get(Key{GroupId: 1, UserId: 2}).Step)
+	assert.Empty(m.Get(Key{GroupId: 1, UserId: 2}).Data)
+}
@@ -0,0 +1,120 @@
+package fsm
+
+import (
+	"os"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/nekoite/go-napcat/utils"
+)
+
+// Store 会话的持久化存储。
+type Store interface {
+	// Load 读取所有保存的会话
+	Load() (map[Key]*State, error)
+	// Save 保存会话。会话的每个步骤结束后都会被调用
+	Save(key Key, state *State) error
+	// Delete 删除会话
+	Delete(key Key) error
+}
+
+// MemoryStore 不做持久化的存储。
+type MemoryStore struct{}
+
+// FileStore 将所有会话以 JSON 格式保存在一个文件中的存储。
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+	// sessions 保存序列化后的会话，避免在写入文件时读取正在被修改的会话
+	sessions map[Key]json.RawMessage
+}
+
+type fileStoreRecord struct {
+	Key   Key             `json:"key"`
+	State json.RawMessage `json:"state"`
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Load() (map[Key]*State, error) {
+	return make(map[Key]*State), nil
+}
+
+func (s *MemoryStore) Save(key Key, state *State) error {
+	return nil
+}
+
+func (s *MemoryStore) Delete(key Key) error {
+	return nil
+}
+
+// NewFileStore 创建保存在 path 的文件存储。文件不存在时将在第一次保存时创建。
+func NewFileStore(path string) *FileStore {
+	return &FileStore{
+		path:     path,
+		sessions: make(map[Key]json.RawMessage),
+	}
+}
+
+func (s *FileStore) Load() (map[Key]*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[Key]*State), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []fileStoreRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, err
+	}
+	s.sessions = make(map[Key]json.RawMessage, len(records))
+	res := make(map[Key]*State, len(records))
+	for _, r := range records {
+		state := new(State)
+		if err := json.Unmarshal(r.State, state); err != nil {
+			return nil, err
+		}
+		s.sessions[r.Key] = r.State
+		res[r.Key] = state
+	}
+	return res, nil
+}
+
+func (s *FileStore) Save(key Key, state *State) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[key] = raw
+	return s.flush()
+}
+
+func (s *FileStore) Delete(key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[key]; !ok {
+		return nil
+	}
+	delete(s.sessions, key)
+	return s.flush()
+}
+
+// flush 将所有会话写入文件。
+func (s *FileStore) flush() error {
+	records := make([]fileStoreRecord, 0, len(s.sessions))
+	for k, v := range s.sessions {
+		records = append(records, fileStoreRecord{Key: k, State: v})
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.path, b)
+}
@@ -0,0 +1,199 @@
+// Package i18n 提供库生成的消息（权限不足、冷却、参数错误、帮助等）的多语言支持。
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Args 消息模板中 {name} 占位符的值
+type Args map[string]any
+
+//go:embed locales/*.yaml
+var builtinLocales embed.FS
+
+// DefaultLocale 内置消息的默认语言
+const DefaultLocale = "zh-CN"
+
+// Catalog 按语言保存消息模板。
+//
+// 查找消息时依次尝试指定的语言、语言的基本部分（例如 en-US 的 en）以及后备语言，都找不到时使用键本身作为模板，
+// 所以没有定义的键可以直接作为文本使用。
+type Catalog struct {
+	mu       sync.RWMutex
+	fallback string
+	messages map[string]map[string]string
+}
+
+// NewCatalog 创建空的消息目录。
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: fallback,
+		messages: make(map[string]map[string]string),
+	}
+}
+
+// DefaultCatalog 创建包含内置的 zh-CN 与 en-US 消息的消息目录，后备语言为 zh-CN。
+func DefaultCatalog() *Catalog {
+	c := NewCatalog(DefaultLocale)
+	entries, err := builtinLocales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		data, err := builtinLocales.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		if err := c.Load(localeOf(entry.Name()), data); err != nil {
+			panic(err)
+		}
+	}
+	return c
+}
+
+// Fallback 返回后备语言。
+func (c *Catalog) Fallback() string {
+	return c.fallback
+}
+
+// Locales 返回所有有消息的语言。
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Sorted(maps.Keys(c.messages))
+}
+
+// Set 设置一条消息模板。
+func (c *Catalog) Set(locale string, key string, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	c.messages[locale][key] = message
+}
+
+// Load 从 YAML 加载 locale 的消息，已有的同名消息将被覆盖。嵌套的键使用 . 连接，例如
+//
+//	help:
+//	  header: 可用指令：
+//
+// 的键为 help.header。
+func (c *Catalog) Load(locale string, data []byte) error {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	messages := make(map[string]string)
+	flatten("", raw, messages)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	maps.Copy(c.messages[locale], messages)
+	return nil
+}
+
+// LoadFile 加载一个 YAML 消息文件，文件名（不含扩展名）为语言，例如 en-US.yaml。
+func (c *Catalog) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.Load(localeOf(path), data)
+}
+
+// LoadDir 加载目录中所有的 .yaml 与 .yml 消息文件。
+func (c *Catalog) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		if err := c.LoadFile(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Lookup 查找 locale 的消息模板，找不到时返回 false。
+func (c *Catalog) Lookup(locale string, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, l := range c.candidates(locale) {
+		if msg, ok := c.messages[l][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// Translate 返回 locale 的消息，并替换其中的占位符。找不到消息时使用 key 本身作为模板。
+func (c *Catalog) Translate(locale string, key string, args Args) string {
+	msg, ok := c.Lookup(locale, key)
+	if !ok {
+		msg = key
+	}
+	return Format(msg, args)
+}
+
+// candidates 返回查找消息时依次尝试的语言。
+func (c *Catalog) candidates(locale string) []string {
+	res := []string{locale}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		res = append(res, base)
+	}
+	if c.fallback != "" && c.fallback != locale {
+		res = append(res, c.fallback)
+	}
+	return res
+}
+
+// Format 将模板中的 {name} 替换为 args 中对应的值。
+func Format(template string, args Args) string {
+	if len(args) == 0 {
+		return template
+	}
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+func flatten(prefix string, raw map[string]any, out map[string]string) {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(key, v, out)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func localeOf(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadNested(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCatalog("zh-CN")
+	assert.NoError(c.Load("zh-CN", []byte("help:\n  header: 可用指令：\n  args:\n    page: 页码\ncount: 3\n")))
+	msg, ok := c.Lookup("zh-CN", "help.header")
+	assert.True(ok)
+	assert.Equal("可用指令：", msg)
+	msg, _ = c.Lookup("zh-CN", "help.args.page")
+	assert.Equal("页码", msg)
+	msg, _ = c.Lookup("zh-CN", "count")
+	assert.Equal("3", msg)
+	assert.Error(c.Load("zh-CN", []byte("- a\n- b\n")))
+}
+
+func TestTranslateFallback(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCatalog("zh-CN")
+	c.Set("zh-CN", "greet", "你好，{name}")
+	c.Set("zh-CN", "bye", "再见")
+	c.Set("en", "greet", "Hello, {name}")
+	c.Set("en-GB", "bye", "Cheerio")
+
+	assert.Equal("Hello, Alice", c.Translate("en-US", "greet", Args{"name": "Alice"}))
+	assert.Equal("Cheerio", c.Translate("en-GB", "bye", nil))
+	assert.Equal("再见", c.Translate("en-US", "bye", nil))
+	assert.Equal("你好，小明", c.Translate("ja", "greet", Args{"name": "小明"}))
+	// 找不到的键本身作为模板
+	assert.Equal("等待 3 秒", c.Translate("en-US", "等待 {seconds} 秒", Args{"seconds": 3}))
+	assert.Equal([]string{"en", "en-GB", "zh-CN"}, c.Locales())
+}
+
+func TestDefaultCatalog(t *testing.T) {
+	assert := assert.New(t)
+	c := DefaultCatalog()
+	assert.Equal(DefaultLocale, c.Fallback())
+	assert.Equal("请在 5 秒后再试", c.Translate("zh-CN", "command.cooldown", Args{"seconds": 5}))
+	assert.Equal("Please try again in 5 seconds", c.Translate("en-US", "command.cooldown", Args{"seconds": 5}))
+	// 内置的语言包含相同的键
+	zh, en := NewCatalog(""), NewCatalog("")
+	data, _ := builtinLocales.ReadFile("locales/zh-CN.yaml")
+	assert.NoError(zh.Load("zh-CN", data))
+	data, _ = builtinLocales.ReadFile("locales/en-US.yaml")
+	assert.NoError(en.Load("en-US", data))
+	for key := range zh.messages["zh-CN"] {
+		_, ok := en.Lookup("en-US", key)
+		assert.True(ok, key)
+	}
+	assert.Len(en.messages["en-US"], len(zh.messages["zh-CN"]))
+}
+
+func TestLoadDir(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(dir, "en-US.yaml"), []byte("command:\n  quota: No more today\n"), 0o644))
+	assert.NoError(os.WriteFile(filepath.Join(dir, "ja.yml"), []byte("command:\n  quota: 今日はもう使えません\n"), 0o644))
+	assert.NoError(os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a catalog"), 0o644))
+	c := DefaultCatalog()
+	assert.NoError(c.LoadDir(dir))
+	assert.Equal("No more today", c.Translate("en-US", "command.quota", nil))
+	assert.Equal("今日はもう使えません", c.Translate("ja", "command.quota", nil))
+	// 没有覆盖的消息保持不变
+	assert.Equal("Please try again in 1 seconds", c.Translate("en-US", "command.cooldown", Args{"seconds": 1}))
+
+	assert.Error(c.LoadDir(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,274 @@
+package message
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/nekoite/go-napcat/qq"
+)
+
+// ChainBuilder 以链式调用构建消息链，例如
+//
+//	chain, err := message.Build().Reply(id).At(uid).Text("hi ").Face(14).Image("file:///tmp/a.png", message.Flash()).Build()
+//
+// 添加片段时会检查片段的组合是否有效（见 [Chain.Validate]），第一个错误会被记录，之后的调用不再添加片段，由 Build 返回。
+type ChainBuilder struct {
+	chain *Chain
+	err   error
+}
+
+// FileOption 图片、语音、视频消息片段的选项
+type FileOption func(seg Segment)
+
+// Build 创建新的消息链构建器。
+func Build() *ChainBuilder {
+	return &ChainBuilder{chain: NewChain()}
+}
+
+// Segment 添加一个消息片段。
+func (b *ChainBuilder) Segment(seg Segment) *ChainBuilder {
+	if b.err != nil {
+		return b
+	}
+	if err := checkSegment(b.chain.Messages, seg); err != nil {
+		b.err = err
+		return b
+	}
+	if seg.Type == SegmentTypeReply {
+		// 回复总是在消息的开头
+		b.chain.PrependSegment(seg)
+	} else {
+		b.chain.AddSegment(seg)
+	}
+	return b
+}
+
+// Chain 添加另一条消息链中的所有片段，chain 为 nil 时不做任何事。
+func (b *ChainBuilder) Chain(chain *Chain) *ChainBuilder {
+	if chain == nil {
+		return b
+	}
+	for _, seg := range chain.Messages {
+		b.Segment(seg)
+	}
+	return b
+}
+
+func (b *ChainBuilder) Text(text string) *ChainBuilder {
+	return b.Segment(NewText(text).Segment())
+}
+
+func (b *ChainBuilder) Textf(format string, args ...any) *ChainBuilder {
+	return b.Text(fmt.Sprintf(format, args...))
+}
+
+func (b *ChainBuilder) Face(id int64) *ChainBuilder {
+	return b.Segment(NewFace(id).Segment())
+}
+
+func (b *ChainBuilder) At(userId qq.UserId) *ChainBuilder {
+	return b.Segment(NewAtUser(userId).Segment())
+}
+
+func (b *ChainBuilder) AtAll() *ChainBuilder {
+	return b.Segment(NewAtAll().Segment())
+}
+
+// Image 添加图片，file 可以使用 [Base64File]、[LocalFile] 或 [URLFile] 生成。
+func (b *ChainBuilder) Image(file string, options ...FileOption) *ChainBuilder {
+	return b.Segment(applyFileOptions(NewImage(file).Segment(), options))
+}
+
+// Record 添加语音，file 的格式与 Image 相同。
+func (b *ChainBuilder) Record(file string, options ...FileOption) *ChainBuilder {
+	return b.Segment(applyFileOptions(NewRecord(file).Segment(), options))
+}
+
+// Video 添加短视频，file 的格式与 Image 相同。
+func (b *ChainBuilder) Video(file string, options ...FileOption) *ChainBuilder {
+	return b.Segment(applyFileOptions(NewVideo(file).Segment(), options))
+}
+
+// Reply 回复消息 id，回复片段总是被放在消息的开头，一条消息只能回复一条消息。
+func (b *ChainBuilder) Reply(id qq.MessageId) *ChainBuilder {
+	return b.Segment(NewReply(id).Segment())
+}
+
+func (b *ChainBuilder) Music(t MusicType, id int64) *ChainBuilder {
+	return b.Segment(NewMusic(t, id).Segment())
+}
+
+func (b *ChainBuilder) Json(data string) *ChainBuilder {
+	return b.Segment(NewJson(data).Segment())
+}
+
+func (b *ChainBuilder) Xml(data string) *ChainBuilder {
+	return b.Segment(NewXml(data).Segment())
+}
+
+// Node 添加合并转发节点，引用已有的消息 id。合并转发节点不能与其它片段混合。
+func (b *ChainBuilder) Node(id int64) *ChainBuilder {
+	return b.Segment(NewNode(id).Segment())
+}
+
+// CustomNode 添加自定义的合并转发节点。合并转发节点不能与其它片段混合。
+func (b *ChainBuilder) CustomNode(userId qq.UserId, nickname string, content *Chain) *ChainBuilder {
+	return b.Segment(NewCustomNode(userId, nickname, content).Segment())
+}
+
+// Err 返回构建过程中的第一个错误。
+func (b *ChainBuilder) Err() error {
+	return b.err
+}
+
+// Build 返回构建好的消息链的深拷贝（见 [Chain.Clone]），之后对构建器或其它返回的消息链的修改不会影响它。
+// 片段的组合无效时返回 [errors.ErrInvalidMessage]。
+func (b *ChainBuilder) Build() (*Chain, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.chain.Clone(), nil
+}
+
+// MustBuild 与 Build 相同，但在出错时 panic。
+func (b *ChainBuilder) MustBuild() *Chain {
+	chain, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return chain
+}
+
+// Validate 检查消息链中片段的组合：最多只能有一个回复片段，合并转发节点不能与其它片段混合。
+func (mc *Chain) Validate() error {
+	for i, seg := range mc.Messages {
+		if err := checkSegment(mc.Messages[:i], seg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSegment 检查 seg 能否加入已有的片段 existing 中。
+func checkSegment(existing []Segment, seg Segment) error {
+	for _, other := range existing {
+		if seg.Type == SegmentTypeReply && other.Type == SegmentTypeReply {
+			return fmt.Errorf("%w: only one reply segment is allowed", errors.ErrInvalidMessage)
+		}
+		if (seg.Type == SegmentTypeNode) != (other.Type == SegmentTypeNode) {
+			return fmt.Errorf("%w: node segments cannot be mixed with %s segments", errors.ErrInvalidMessage, nonNodeType(seg, other))
+		}
+	}
+	return nil
+}
+
+func nonNodeType(a, b Segment) SegmentType {
+	if a.Type == SegmentTypeNode {
+		return b.Type
+	}
+	return a.Type
+}
+
+func applyFileOptions(seg Segment, options []FileOption) Segment {
+	for _, option := range options {
+		option(seg)
+	}
+	return seg
+}
+
+// basicFileData 返回图片、语音、视频消息片段的文件数据，其它片段返回 nil。
+func basicFileData(seg Segment) *BasicFileData {
+	switch d := seg.Data.(type) {
+	case *ImageData:
+		return &d.BasicFileData
+	case *RecordData:
+		return &d.BasicFileData
+	case *VideoData:
+		return (*BasicFileData)(d)
+	}
+	return nil
+}
+
+func intOf(b bool) *int {
+	i := 0
+	if b {
+		i = 1
+	}
+	return &i
+}
+
+// Flash 将图片设置为闪照，对其它片段无效。
+func Flash() FileOption {
+	return func(seg Segment) {
+		if d, ok := seg.Data.(*ImageData); ok {
+			d.SetFlash()
+		}
+	}
+}
+
+// Magic 将语音设置为变声，对其它片段无效。
+func Magic() FileOption {
+	return func(seg Segment) {
+		if d, ok := seg.Data.(*RecordData); ok {
+			d.Magic = 1
+		}
+	}
+}
+
+// Cache 设置通过网络 URL 发送时是否使用已缓存的文件。
+func Cache(enabled bool) FileOption {
+	return func(seg Segment) {
+		if d := basicFileData(seg); d != nil {
+			d.Cache = intOf(enabled)
+		}
+	}
+}
+
+// Proxy 设置通过网络 URL 发送时是否通过代理下载文件。
+func Proxy(enabled bool) FileOption {
+	return func(seg Segment) {
+		if d := basicFileData(seg); d != nil {
+			d.Proxy = intOf(enabled)
+		}
+	}
+}
+
+// Timeout 设置通过网络 URL 发送时下载文件的超时时间，单位秒。
+func Timeout(seconds int) FileOption {
+	return func(seg Segment) {
+		if d := basicFileData(seg); d != nil {
+			d.Timeout = &seconds
+		}
+	}
+}
+
+// Base64File 返回以 base64:// 编码的文件内容，可以作为图片、语音、视频的 file。
+func Base64File(data []byte) string {
+	return "base64://" + base64.StdEncoding.EncodeToString(data)
+}
+
+// LocalFile 返回本地文件的 file:// URI，相对路径将转换为绝对路径。注意文件需要能被 OneBot 实现访问。
+func LocalFile(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows 路径，例如 C:/a.png
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// URLFile 返回转义后的网络文件 URL，例如路径中的中文会被转义。无法解析的 URL 原样返回。
+func URLFile(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.String()
+}
@@ -0,0 +1,121 @@
+package message
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nekoite/go-napcat/errors"
+	"github.com/nekoite/go-napcat/qq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	assert := assert.New(t)
+	chain, err := Build().At(123).Text("hi ").Face(14).Image("file:///tmp/a.png", Flash()).Reply(42).Build()
+	assert.NoError(err)
+	if assert.Equal(5, chain.Len()) {
+		assert.Equal(NewReply(42).Segment(), chain.At(0))
+		assert.Equal(NewAt("123").Segment(), chain.At(1))
+		assert.Equal("hi ", chain.FirstText().Text)
+		assert.Equal(NewFace(14).Segment(), chain.At(3))
+		assert.True(chain.FirstImage().IsFlash())
+		assert.Equal("file:///tmp/a.png", chain.FirstImage().File)
+	}
+}
+
+func TestBuilderReturnsCopy(t *testing.T) {
+	assert := assert.New(t)
+	b := Build().Text("a")
+	chain := b.MustBuild()
+	b.Text("b").Reply(1)
+	assert.Equal(1, chain.Len())
+	assert.Equal(3, b.MustBuild().Len())
+	assert.Equal(1, Build().Chain(nil).Text("a").MustBuild().Len())
+
+	// 发送时引用消息会替换构建器中的回复
+	chain = Build().Reply(1).Text("a").MustBuild()
+	chain.SetReplyTo(2)
+	assert.NoError(chain.Validate())
+	assert.EqualValues(2, chain.At(0).GetReplyData().Id)
+}
+
+func TestBuilderReturnsDeepCopy(t *testing.T) {
+	assert := assert.New(t)
+	b := Build().Image("a.png", Cache(true)).Reply(1)
+	first := b.MustBuild()
+	second := b.MustBuild()
+	// 修改一条消息链中的片段不会影响构建器以及其它已经返回的消息链
+	Flash()(second.At(1))
+	Timeout(5)(second.At(1))
+	*second.At(1).GetImageData().Cache = 0
+	second.SetReplyTo(2)
+	for _, chain := range []*Chain{first, b.MustBuild()} {
+		image := chain.At(1).GetImageData()
+		assert.False(image.IsFlash())
+		assert.Nil(image.Timeout)
+		assert.Equal(1, *image.Cache)
+		assert.EqualValues(1, chain.At(0).GetReplyData().Id)
+	}
+}
+
+func TestChainClone(t *testing.T) {
+	assert := assert.New(t)
+	var nilChain *Chain
+	assert.Nil(nilChain.Clone())
+	content := NewChain(NewText("a").Segment())
+	chain := Build().CustomNode(1, "n", content).MustBuild()
+	clone := chain.Clone()
+	assert.Equal(chain, clone)
+	clone.At(0).Data.(*CustomNodeData).Content.(*Chain).FirstText().Text = "b"
+	assert.Equal("a", content.FirstText().Text)
+	unknown := Segment{Type: "unknown", Data: UnknownData{"a": []any{1}}}
+	c := unknown.Clone()
+	c.Data.(UnknownData)["a"].([]any)[0] = 2
+	assert.Equal(1, unknown.Data.(UnknownData)["a"].([]any)[0])
+}
+
+func TestBuilderFileOptions(t *testing.T) {
+	assert := assert.New(t)
+	chain := Build().Record("a.amr", Magic(), Cache(false), Flash()).MustBuild()
+	record := chain.At(0).GetRecordData()
+	assert.Equal(1, record.Magic)
+	assert.Equal(0, *record.Cache)
+	assert.Nil(record.Proxy)
+
+	chain = Build().Video("https://example.com/a.mp4", Proxy(true), Timeout(30), Magic()).MustBuild()
+	video := chain.At(0).GetVideoData()
+	assert.Equal(1, *video.Proxy)
+	assert.Equal(30, *video.Timeout)
+}
+
+func TestBuilderValidation(t *testing.T) {
+	assert := assert.New(t)
+	_, err := Build().Reply(1).Text("a").Reply(2).Build()
+	assert.ErrorIs(err, errors.ErrInvalidMessage)
+
+	b := Build().CustomNode(1, "a", Build().Text("x").MustBuild()).Node(2).Text("b").Node(3)
+	assert.ErrorIs(b.Err(), errors.ErrInvalidMessage)
+	_, err = b.Build()
+	assert.ErrorContains(err, "node segments cannot be mixed with text segments")
+	assert.Panics(func() { b.MustBuild() })
+
+	_, err = Build().Text("a").Node(1).Build()
+	assert.ErrorIs(err, errors.ErrInvalidMessage)
+
+	chain, err := Build().Node(1).CustomNode(qq.UserId(2), "b", NewChain()).Build()
+	assert.NoError(err)
+	assert.Equal(2, chain.Len())
+
+	assert.NoError(NewChain(NewReply(1).Segment(), NewTextSegment("a")).Validate())
+	assert.ErrorIs(NewChain(NewReply(1).Segment(), NewReply(2).Segment()).Validate(), errors.ErrInvalidMessage)
+}
+
+func TestFileSources(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("base64://aGk=", Base64File([]byte("hi")))
+	assert.Equal("file:///tmp/a%20b.png", LocalFile("/tmp/a b.png"))
+	abs, _ := filepath.Abs("a.png")
+	assert.Equal("file://"+filepath.ToSlash(abs), LocalFile("a.png"))
+	assert.Equal("https://example.com/%E6%AD%8C.mp3?a=1", URLFile("https://example.com/歌.mp3?a=1"))
+	assert.Equal("://bad", URLFile("://bad"))
+}
@@ -18,6 +18,18 @@ func NewChain(msg ...Segment) *Chain {
 	return &Chain{Messages: msg}
 }
 
+// Clone 返回消息链的深拷贝，修改拷贝中的片段不会影响原消息链。
+func (mc *Chain) Clone() *Chain {
+	if mc == nil {
+		return nil
+	}
+	messages := make([]Segment, len(mc.Messages))
+	for i, seg := range mc.Messages {
+		messages[i] = seg.Clone()
+	}
+	return NewChain(messages...)
+}
+
 func (mc *Chain) PrependSegment(msg Segment) {
 	mc.Messages = append([]Segment{msg}, mc.Messages...)
 }
@@ -59,8 +71,12 @@ func (mc *Chain) SetSendAsAnonymous(ignore bool) {
 	mc.PrependSegment(NewAnonymous(ignore).Segment())
 }
 
-// SetReplyTo 设置回复的消息 ID。
+// SetReplyTo 设置回复的消息 ID。消息链中已经有回复片段时替换它，一条消息只能回复一条消息。
 func (mc *Chain) SetReplyTo(msgId qq.MessageId) {
+	if seg := mc.FirstOfTypeRef(SegmentTypeReply); seg != nil {
+		seg.Data = NewReply(msgId)
+		return
+	}
 	mc.PrependSegment(NewReply(msgId).Segment())
 }
 
@@ -126,6 +126,12 @@ func TestSetReplyTo(t *testing.T) {
 	assert.Len(chain.Messages, 2)
 	assert.IsType(&ReplyData{}, chain.Messages[0].Data)
 	assert.EqualValues(123456, chain.Messages[0].Data.(*ReplyData).Id)
+
+	// 已有回复片段时替换它
+	chain.SetReplyTo(654321)
+	assert.Len(chain.Messages, 2)
+	assert.EqualValues(654321, chain.Messages[0].Data.(*ReplyData).Id)
+	assert.NoError(chain.Validate())
 }
 
 func TestPrependChain(t *testing.T) {
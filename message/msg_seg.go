@@ -268,6 +268,64 @@ func (m Segment) GetJsonData() *JsonData {
 	return GetMsgData[JsonData](&m)
 }
 
+// Clone 返回消息片段的深拷贝，修改拷贝的数据不会影响原片段。
+func (m Segment) Clone() Segment {
+	if m.Data == nil {
+		return m
+	}
+	return Segment{Type: m.Type, Data: deepCopy(reflect.ValueOf(m.Data)).Interface()}
+}
+
+// deepCopy 递归复制指针、接口、结构体、切片与映射指向的数据。
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
 func (m Segment) IsInvalid() bool {
 	return m.Type == ""
 }
@@ -0,0 +1,24 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nekoite/go-napcat/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.json")
+	assert.NoError(utils.WriteFileAtomic(path, []byte("1")))
+	assert.NoError(utils.WriteFileAtomic(path, []byte("2")))
+	b, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("2", string(b))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(err)
+	assert.Len(entries, 1)
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// TTLSet 带有过期时间与容量上限的集合，并发安全。超出容量时最早加入的元素将被移除。
+type TTLSet[T comparable] struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	items    map[T]*list.Element
+	// order 按加入顺序（也即过期顺序）保存元素
+	order *list.List
+	now   func() time.Time
+}
+
+type ttlSetEntry[T comparable] struct {
+	item      T
+	expiresAt time.Time
+}
+
+// NewTTLSet 创建集合。ttl 为元素的存活时间，capacity 为容量上限，小于等于 0 时不限制容量。
+func NewTTLSet[T comparable](ttl time.Duration, capacity int) *TTLSet[T] {
+	return &TTLSet[T]{
+		ttl:      ttl,
+		capacity: capacity,
+		items:    make(map[T]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// AddIfAbsent 在元素不存在（或已过期）时加入元素并返回 true，否则返回 false。
+func (s *TTLSet[T]) AddIfAbsent(item T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.evictExpired(now)
+	if _, ok := s.items[item]; ok {
+		return false
+	}
+	if s.capacity > 0 && s.order.Len() >= s.capacity {
+		s.removeElement(s.order.Front())
+	}
+	s.items[item] = s.order.PushBack(ttlSetEntry[T]{item: item, expiresAt: now.Add(s.ttl)})
+	return true
+}
+
+// Contains 返回元素是否存在且未过期。
+func (s *TTLSet[T]) Contains(item T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired(s.now())
+	_, ok := s.items[item]
+	return ok
+}
+
+func (s *TTLSet[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired(s.now())
+	return s.order.Len()
+}
+
+func (s *TTLSet[T]) evictExpired(now time.Time) {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		if now.Before(e.Value.(ttlSetEntry[T]).expiresAt) {
+			return
+		}
+		s.removeElement(e)
+	}
+}
+
+func (s *TTLSet[T]) removeElement(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.items, e.Value.(ttlSetEntry[T]).item)
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLSetAddIfAbsent(t *testing.T) {
+	assert := assert.New(t)
+	s := NewTTLSet[string](time.Minute, 0)
+	assert.True(s.AddIfAbsent("a"))
+	assert.False(s.AddIfAbsent("a"))
+	assert.True(s.AddIfAbsent("b"))
+	assert.True(s.Contains("a"))
+	assert.Equal(2, s.Len())
+}
+
+func TestTTLSetExpire(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	s := NewTTLSet[string](time.Second, 0)
+	s.now = func() time.Time { return now }
+	assert.True(s.AddIfAbsent("a"))
+	now = now.Add(500 * time.Millisecond)
+	assert.True(s.AddIfAbsent("b"))
+	now = now.Add(600 * time.Millisecond)
+	assert.False(s.Contains("a"))
+	assert.True(s.Contains("b"))
+	assert.True(s.AddIfAbsent("a"))
+	assert.Equal(2, s.Len())
+}
+
+func TestTTLSetCapacity(t *testing.T) {
+	assert := assert.New(t)
+	s := NewTTLSet[int](time.Minute, 2)
+	assert.True(s.AddIfAbsent(1))
+	assert.True(s.AddIfAbsent(2))
+	assert.True(s.AddIfAbsent(3))
+	assert.False(s.Contains(1))
+	assert.True(s.Contains(2))
+	assert.True(s.Contains(3))
+	assert.Equal(2, s.Len())
+}
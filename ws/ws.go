@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,14 +16,21 @@ import (
 type Client struct {
 	logger    *zap.Logger
 	setupFunc func() (*websocket.Conn, error)
+	// mu 保护 conn。读写协程使用各自创建时的连接，conn 只用于强制重新连接
+	mu        sync.Mutex
 	conn      *websocket.Conn
-	interrupt chan struct{}
-	send      chan []byte
-	stopped   atomic.Bool
+	closed    chan struct{}
+	closeOnce sync.Once
+	// wake 使正在等待重试的重连立即重试
+	wake    chan struct{}
+	send    chan []byte
+	stopped atomic.Bool
 
-	writeWait  time.Duration
-	pongWait   time.Duration
-	pingPeriod time.Duration
+	writeWait     time.Duration
+	pongWait      time.Duration
+	pingPeriod    time.Duration
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
 
 	onRecvMsg func([]byte)
 }
@@ -45,48 +53,94 @@ func NewConn(logger *zap.Logger, cfg *config.BotConfig, onRecvMsg func([]byte))
 		logger.Error("dial:", zap.Error(err))
 		return nil, err
 	}
-	interrupt := make(chan struct{}, 1)
+	retryDelay := time.Duration(cfg.Ws.RetryDelay) * time.Millisecond
+	if retryDelay <= 0 {
+		retryDelay = time.Second
+	}
+	maxRetryDelay := time.Duration(cfg.Ws.MaxRetryDelay) * time.Millisecond
+	if maxRetryDelay < retryDelay {
+		maxRetryDelay = retryDelay
+	}
 	wsConn := &Client{
-		logger:     logger,
-		setupFunc:  setupFunc,
-		conn:       conn,
-		interrupt:  interrupt,
-		send:       make(chan []byte, 256),
-		stopped:    atomic.Bool{},
-		writeWait:  time.Duration(cfg.Ws.Timeout) * time.Millisecond,
-		pongWait:   time.Duration(cfg.Ws.PongTimeout) * time.Millisecond,
-		pingPeriod: time.Duration(cfg.Ws.PingPeriod) * time.Millisecond,
-		onRecvMsg:  onRecvMsg,
+		logger:        logger,
+		setupFunc:     setupFunc,
+		conn:          conn,
+		closed:        make(chan struct{}),
+		wake:          make(chan struct{}, 1),
+		send:          make(chan []byte, 256),
+		stopped:       atomic.Bool{},
+		writeWait:     time.Duration(cfg.Ws.Timeout) * time.Millisecond,
+		pongWait:      time.Duration(cfg.Ws.PongTimeout) * time.Millisecond,
+		pingPeriod:    time.Duration(cfg.Ws.PingPeriod) * time.Millisecond,
+		retryDelay:    retryDelay,
+		maxRetryDelay: maxRetryDelay,
+		onRecvMsg:     onRecvMsg,
 	}
 	return wsConn, nil
 }
 
 func (c *Client) Start() {
-	c.setupConn()
+	c.setupConn(c.getConn())
 }
 
-func (c *Client) retry() error {
-	if c.stopped.Load() {
-		return nil
-	}
-	var err error
-	c.logger.Warn("connection closed unexpectedly, reconnecting...")
-	c.conn, err = c.setupFunc()
-	if err != nil {
-		c.logger.Error("failed to reconnect", zap.Error(err))
-		return err
+func (c *Client) getConn() *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+// retry 在连接断开后重新连接，失败时按指数退避一直重试，直到成功或者客户端被关闭。
+func (c *Client) retry() {
+	delay := c.retryDelay
+	for attempt := 1; ; attempt++ {
+		if c.stopped.Load() {
+			return
+		}
+		if attempt == 1 {
+			c.logger.Warn("connection closed unexpectedly, reconnecting...")
+		}
+		conn, err := c.setupFunc()
+		if err == nil {
+			c.mu.Lock()
+			if c.stopped.Load() {
+				c.mu.Unlock()
+				conn.Close()
+				return
+			}
+			c.conn = conn
+			c.mu.Unlock()
+			c.setupConn(conn)
+			return
+		}
+		c.logger.Error("failed to reconnect", zap.Error(err), zap.Int("attempt", attempt), zap.Duration("retryIn", delay))
+		select {
+		case <-c.closed:
+			return
+		case <-c.wake:
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, c.maxRetryDelay)
 	}
-	c.setupConn()
-	return nil
 }
 
-func (c *Client) setupConn() {
-	go c.readPump()
-	go c.writePump()
+func (c *Client) setupConn(conn *websocket.Conn) {
+	// done 在读取结束时关闭，通知同一连接的 writePump 退出，避免重连后出现多个 writePump
+	done := make(chan struct{})
+	go c.readPump(conn, done)
+	go c.writePump(conn, done)
 }
 
-func (c *Client) readPump() {
-	conn := c.conn
+// Reconnect 强制断开当前连接并重新连接。正在等待重试时立即重试。
+func (c *Client) Reconnect() {
+	c.logger.Warn("forcing reconnect")
+	c.getConn().Close()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Client) readPump(conn *websocket.Conn, done chan struct{}) {
 	defer func() {
 		conn.Close()
 	}()
@@ -106,34 +160,38 @@ func (c *Client) readPump() {
 		}
 		c.logger.Debug("wsrecv", zap.String("message", string(message)))
 		if c.onRecvMsg != nil {
-			go c.onRecvMsg(message)
+			// 回调需要自行决定是否异步处理，以便保持消息的顺序
+			c.onRecvMsg(message)
 		}
 	}
-	err := c.retry()
-	if err != nil {
-		return
+	close(done)
+	// 丢弃强制重连在连接仍然正常时留下的唤醒信号
+	select {
+	case <-c.wake:
+	default:
 	}
+	c.retry()
 }
 
-func (c *Client) writePump() {
+func (c *Client) writePump(conn *websocket.Conn, done chan struct{}) {
 	ticker := time.NewTicker(c.pingPeriod)
-	conn := c.conn
 	defer func() {
 		ticker.Stop()
 		conn.Close()
 	}()
 	for {
 		select {
+		case <-done:
+			return
 		case <-ticker.C:
-			if err := c.writeMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.writeMessage(conn, websocket.PingMessage, nil); err != nil {
 				c.logger.Error("wssend", zap.Error(err))
 				return
 			}
 			c.logger.Debug("sent ping")
-		case <-c.interrupt:
+		case <-c.closed:
 			c.logger.Info("ws connection close")
-			c.stopped.Store(true)
-			err := c.writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			err := c.writeMessage(conn, websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 			if err != nil {
 				c.logger.Error("close", zap.Error(err))
 			}
@@ -141,11 +199,11 @@ func (c *Client) writePump() {
 		case message, ok := <-c.send:
 			if !ok {
 				c.logger.Error("send channel closed")
-				c.writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				c.writeMessage(conn, websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 				return
 			}
 			c.logger.Debug("wssend", zap.String("message", string(message)))
-			if err := c.writeMessage(websocket.TextMessage, message); err != nil {
+			if err := c.writeMessage(conn, websocket.TextMessage, message); err != nil {
 				c.logger.Error("wssend", zap.Error(err))
 				return
 			}
@@ -153,13 +211,15 @@ func (c *Client) writePump() {
 	}
 }
 
-func (c *Client) writeMessage(messageType int, data []byte) error {
-	c.conn.SetWriteDeadline(time.Now().Add(c.writeWait))
-	return c.conn.WriteMessage(messageType, data)
+func (c *Client) writeMessage(conn *websocket.Conn, messageType int, data []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(c.writeWait))
+	return conn.WriteMessage(messageType, data)
 }
 
+// Close 关闭连接，之后不再重新连接。
 func (c *Client) Close() {
-	c.interrupt <- struct{}{}
+	c.stopped.Store(true)
+	c.closeOnce.Do(func() { close(c.closed) })
 }
 
 func (c *Client) Send(msg []byte) {
@@ -0,0 +1,96 @@
+package ws
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nekoite/go-napcat/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// testServer 记录连接次数的 WebSocket 服务器，available 为 false 时拒绝连接
+type testServer struct {
+	*httptest.Server
+	connections atomic.Int32
+	available   atomic.Bool
+}
+
+func newTestServer() *testServer {
+	s := &testServer{}
+	s.available.Store(true)
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.available.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.connections.Add(1)
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func newTestClient(t *testing.T, s *testServer) *Client {
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	cfg := config.DefaultBotConfig(1, "")
+	cfg.Ws.Host = host
+	cfg.Ws.Port, _ = strconv.Atoi(port)
+	cfg.Ws.RetryDelay = 10
+	cfg.Ws.MaxRetryDelay = 20
+	c, err := NewConn(zap.NewNop(), cfg, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	c.Start()
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestReconnectRetriesUntilAvailable(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+	assert.Eventually(t, func() bool { return s.connections.Load() == 1 }, time.Second, time.Millisecond)
+
+	// 服务器不可用时一直重试，恢复后重新连接
+	s.available.Store(false)
+	old := c.getConn()
+	c.Reconnect()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), s.connections.Load())
+	s.available.Store(true)
+	assert.Eventually(t, func() bool { return s.connections.Load() == 2 && c.getConn() != old }, time.Second, time.Millisecond)
+
+	c.Reconnect()
+	assert.Eventually(t, func() bool { return s.connections.Load() == 3 }, time.Second, time.Millisecond)
+}
+
+func TestCloseStopsRetrying(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+	assert.Eventually(t, func() bool { return s.connections.Load() == 1 }, time.Second, time.Millisecond)
+	s.available.Store(false)
+	c.Reconnect()
+	time.Sleep(30 * time.Millisecond)
+	c.Close()
+	s.available.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), s.connections.Load())
+}